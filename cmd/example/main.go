@@ -1,92 +1,593 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/config"
 	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/health"
 	"github.com/bratteby/go-service-template/internal/httpserver"
+	"github.com/bratteby/go-service-template/internal/idempotency"
 	"github.com/bratteby/go-service-template/internal/logging"
+	"github.com/bratteby/go-service-template/internal/metrics"
+	"github.com/bratteby/go-service-template/internal/outbox"
 	"github.com/bratteby/go-service-template/internal/postgres"
+	"github.com/bratteby/go-service-template/internal/ratelimit"
+	"github.com/bratteby/go-service-template/internal/resilience"
+	"github.com/bratteby/go-service-template/internal/tracing"
+	"github.com/bratteby/go-service-template/internal/webhook"
+	"github.com/bratteby/go-service-template/migrations"
 )
 
 func main() {
-	// Environment variables.
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], config.ScopeService)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	errorChannel := make(chan error, 1)
+
+	subsystemLevels, err := cfg.Log.SubsystemLevels()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	stacktraceLevel, err := cfg.Log.StacktraceLevel()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	logRateLimits, err := cfg.Log.LogRateLimits()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Logs are written to stderr, and to a rotated file if configured.
 	var (
-		ADDRESS = getEnv("HTTP_ADDRESS", ":80")
-
-		POSTGRES_HOST     = getEnv("POSTGRES_HOST", "localhost")
-		POSTGRES_PORT     = getEnv("POSTGRES_PORT", "5432")
-		POSTGRES_DATABASE = getEnv("POSTGRES_DB", "example")
-		POSTGRES_USER     = getEnv("POSTGRES_USER", "postgres")
-		POSTGRES_PASSWORD = getEnv("POSTGRES_PASSWORD", "")
-		POSTGRES_SSL      = getEnv("POSTGRES_SSL", "disable")
+		logSinks []logging.Sink
+		logFile  *logging.File
 	)
+	if cfg.Log.File.Path != "" {
+		fileLevel, err := cfg.Log.FileLevel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 
-	errorChannel := make(chan error)
+		logFile, err = logging.OpenFile(cfg.Log.File.Config())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		logSinks = append(logSinks, logging.Sink{
+			Writer:   logFile,
+			Encoding: logging.Encoding(cfg.Log.File.Encoding),
+			Level:    fileLevel,
+		})
+	}
 
+	// Levels can be changed at runtime on the admin endpoint /log/levels,
+	// per subsystem of the named loggers.
 	logger := logging.New(nil, logging.Config{
-		Level:         logging.InfoLevel,
-		WithTimeStamp: true,
-		Options:       []logging.Option{},
+		Level:           cfg.Log.Level,
+		Levels:          subsystemLevels,
+		WithTimeStamp:   cfg.Log.WithTimeStamp,
+		Caller:          cfg.Log.Caller,
+		StacktraceLevel: stacktraceLevel,
+		Development:     cfg.Log.Development,
+		Encoding:        logging.Encoding(cfg.Log.Encoding),
+		Color:           cfg.Log.Color,
+		Sinks:           logSinks,
+		Sampling:        cfg.Log.Sampling.Config(),
+		RateLimits:      logRateLimits,
+		Options:         []logging.Option{},
 	})
 
+	logger.InfoWith("loaded configuration", "config", cfg.String())
+
+	// Tracing.
+	shutdownTracing, err := tracing.Setup(cfg.Tracing.Config())
+	if err != nil {
+		logger.Error(err)
+		logger.Sync()
+		os.Exit(1)
+	}
+
+	// Metrics are recorded from setup on, but only served when enabled.
+	registry := metrics.NewRegistry()
+	registry.MustRegister(metrics.NewLogDroppedCollector(logger.Dropped()))
+
 	// Repositories
-	dbPool, err := postgres.NewPool(postgres.ConnectionConfig{
-		User:     POSTGRES_USER,
-		Password: POSTGRES_PASSWORD,
-		Host:     POSTGRES_HOST,
-		Port:     POSTGRES_PORT,
-		DB:       POSTGRES_DATABASE,
-		SSL:      POSTGRES_SSL,
-	})
+	dbPool, err := postgres.NewPool(cfg.Postgres.ConnectionConfig())
 	if err != nil {
 		logger.Error(err)
+		logger.Sync()
 		os.Exit(1)
 	}
 
+	// Schema. Refuse to run against a failed migration or the schema of a
+	// newer binary, older schemas are migrated first if enabled.
+	latestMigration, err := postgres.LatestMigrationVersion(migrations.FS)
+	if err != nil {
+		logger.Error(err)
+		logger.Sync()
+		os.Exit(1)
+	}
+
+	if err := prepareSchema(cfg, dbPool, latestMigration, logger); err != nil {
+		logger.Error(err)
+		logger.Sync()
+		os.Exit(1)
+	}
+
+	// Statements made within a transaction of txManager join it.
+	txManager := &postgres.TxManager{
+		DB:         dbPool,
+		IsoLevel:   pgx.TxIsoLevel(cfg.Postgres.TxIsolation),
+		MaxRetries: cfg.Postgres.TxMaxRetries,
+	}
+
+	tracedDB := postgres.TracedDB{DB: txManager}
+
 	exampleRepository := &postgres.ExampleRepository{
-		DB: dbPool,
+		DB: tracedDB,
+	}
+
+	outboxRepository := &postgres.OutboxRepository{
+		DB: tracedDB,
+	}
+
+	webhookRepository := &postgres.WebhookRepository{
+		DB: tracedDB,
+	}
+
+	idempotencyRepository := &postgres.IdempotencyRepository{
+		DB: tracedDB,
 	}
 
 	// Services.
 	exampleService := example.Service{
 		ExampleRepository: exampleRepository,
-		Logger:            logger,
+		TxManager:         txManager,
+		Events:            outboxRepository,
+		Logger:            logger.Named("example"),
+	}
+
+	if cfg.Resilience.Enabled {
+		exampleService.ExampleRepository = newResilientRepository(cfg.Resilience, exampleRepository, registry, logger)
+	}
+
+	// Outbox relay.
+	publisher, closePublisher, err := newPublisher(cfg.Outbox)
+	if err != nil {
+		logger.Error(err)
+		logger.Sync()
+		os.Exit(1)
+	}
+
+	var publishers outbox.Publishers
+	if publisher != nil {
+		publishers = append(publishers, publisher)
+	}
+
+	if cfg.Webhooks.Enabled {
+		publishers = append(publishers, webhook.Publisher{Store: webhookRepository})
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+
+	// Entries dropped by log sampling and rate limits are reported until
+	// shutdown, as well as exported as metrics.
+	go logger.ReportDropped(workersCtx, cfg.Log.DroppedInterval)
+	relayDone := make(chan struct{})
+
+	if len(publishers) > 0 {
+		notifications := make(chan struct{}, 1)
+		outboxLogger := logger.Named("outbox")
+		go listenOutbox(workersCtx, dbPool, notifications, outboxLogger)
+
+		relay := &outbox.Relay{
+			Store:         outboxRepository,
+			Publisher:     publishers,
+			Logger:        outboxLogger,
+			PollInterval:  cfg.Outbox.PollInterval,
+			BatchSize:     cfg.Outbox.BatchSize,
+			Notifications: notifications,
+			MaxAttempts:   cfg.Outbox.MaxAttempts,
+			BaseBackoff:   cfg.Outbox.BaseBackoff,
+			MaxBackoff:    cfg.Outbox.MaxBackoff,
+			Lease:         cfg.Outbox.Lease,
+		}
+
+		go func() {
+			relay.Run(workersCtx)
+			close(relayDone)
+		}()
+	} else {
+		close(relayDone)
+	}
+
+	// Webhook dispatcher.
+	dispatchDone := make(chan struct{})
+
+	if cfg.Webhooks.Enabled {
+		dispatcher := &webhook.Dispatcher{
+			Store:        webhookRepository,
+			TxManager:    txManager,
+			Client:       webhook.NewClient(cfg.Webhooks.Timeout),
+			Logger:       logger.Named("webhook"),
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			BaseBackoff:  cfg.Webhooks.BaseBackoff,
+			MaxBackoff:   cfg.Webhooks.MaxBackoff,
+			PollInterval: cfg.Webhooks.PollInterval,
+			BatchSize:    cfg.Webhooks.BatchSize,
+			Lease:        cfg.Webhooks.Lease,
+		}
+
+		go func() {
+			dispatcher.Run(workersCtx)
+			close(dispatchDone)
+		}()
+	} else {
+		close(dispatchDone)
+	}
+
+	// Idempotency key purger.
+	purgeDone := make(chan struct{})
+
+	if cfg.Idempotency.Enabled {
+		purger := &idempotency.Purger{
+			Store:    idempotencyRepository,
+			Interval: cfg.Idempotency.PurgeInterval,
+			Logger:   logger.Named("idempotency"),
+		}
+
+		go func() {
+			purger.Run(workersCtx)
+			close(purgeDone)
+		}()
+	} else {
+		close(purgeDone)
+	}
+
+	// Rate limiting.
+	purgeRateLimitsDone := make(chan struct{})
+
+	var rateLimitStore ratelimit.Store
+	if cfg.RateLimit.Enabled && cfg.RateLimit.Store == "postgres" {
+		rateLimitRepository := &postgres.RateLimitRepository{DB: tracedDB}
+		rateLimitStore = rateLimitRepository
+
+		purger := &ratelimit.Purger{
+			Store:    rateLimitRepository,
+			Interval: cfg.RateLimit.PurgeInterval,
+			Logger:   logger.Named("ratelimit"),
+		}
+
+		go func() {
+			purger.Run(workersCtx)
+			close(purgeRateLimitsDone)
+		}()
+	} else {
+		if cfg.RateLimit.Enabled {
+			rateLimitStore = &ratelimit.MemoryStore{}
+		}
+
+		close(purgeRateLimitsDone)
 	}
 
 	// HTTP.
-	go func() {
-		httpServer := httpserver.Server{
-			Address:        ADDRESS,
-			ExampleService: exampleService,
-			Logger:         logger,
+	authenticators, err := newAuthenticators(cfg.Auth, &postgres.APIKeyRepository{DB: tracedDB})
+	if err != nil {
+		logger.Error(err)
+		logger.Sync()
+		os.Exit(1)
+	}
+
+	roles, err := auth.ParseAssignments(cfg.Auth.Roles)
+	if err != nil {
+		logger.Error(err)
+		logger.Sync()
+		os.Exit(1)
+	}
+
+	httpServer := &httpserver.Server{
+		Address:           cfg.HTTP.Address,
+		AdminAddress:      cfg.HTTP.AdminAddress,
+		ExampleService:    exampleService,
+		Logger:            logger.Named("http"),
+		LogLevels:         logger.Levels(),
+		Authenticators:    authenticators,
+		AuthPolicy:        auth.Policy{Roles: roles},
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+
+	if cfg.Idempotency.Enabled {
+		httpServer.IdempotencyStore = idempotencyRepository
+		httpServer.IdempotencyTTL = cfg.Idempotency.TTL
+		httpServer.IdempotencyLockTimeout = cfg.Idempotency.LockTimeout
+	}
+
+	if rateLimitStore != nil {
+		rules, err := cfg.RateLimit.Rules()
+		if err != nil {
+			logger.Error(err)
+			logger.Sync()
+			os.Exit(1)
 		}
 
-		logger.Infof("starting server on: '%s'", ADDRESS)
+		httpServer.RateLimitStore = rateLimitStore
+		httpServer.RateLimitRules = rules
+	}
+
+	if cfg.Webhooks.Enabled {
+		httpServer.WebhookService = webhook.Service{Repository: webhookRepository}
+	}
+
+	// Health checks.
+	healthChecks := &health.Registry{Timeout: cfg.Health.Timeout}
+	healthChecks.AddReadiness("postgres", health.Ping(dbPool))
+
+	if cfg.Health.CheckMigrations {
+		healthChecks.AddReadiness("migrations", health.CheckerFunc(postgres.MigrationCheck(dbPool, latestMigration)))
+	}
+
+	httpServer.Health = healthChecks
+
+	// Metrics.
+	if cfg.Metrics.Enabled {
+		registry.MustRegister(metrics.NewPgxPoolCollector(dbPool))
+
+		httpServer.HTTPMetrics = metrics.NewHTTPMetrics(registry)
+		httpServer.MetricsHandler = metrics.Handler(registry)
+	}
+
+	go func() {
+		logger.Infof("starting server on: '%s'", cfg.HTTP.Address)
+		if cfg.HTTP.AdminAddress != "" {
+			logger.Infof("starting admin server on: '%s'", cfg.HTTP.AdminAddress)
+		}
 
 		errorChannel <- httpServer.Start()
 	}()
 
 	// Capture interrupts.
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, os.Interrupt)
-		errorChannel <- fmt.Errorf("got signal: %s", <-c)
-	}()
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+
+	exitCode := 0
+
+	select {
+	case err := <-errorChannel:
+		if err != nil {
+			logger.Error(fmt.Errorf("http server failed: %w", err))
+			exitCode = 1
+		}
+	case sig := <-signalChannel:
+		logger.Infof("got signal: %s, shutting down", sig)
+	}
+
+	// Fail readiness first and give load balancers time to notice before the
+	// listeners are closed.
+	healthChecks.ShuttingDown()
+	time.Sleep(cfg.HTTP.ShutdownDelay)
 
-	if err := <-errorChannel; err != nil {
+	// Teardown in reverse order of setup: stop accepting requests and drain
+	// in-flight ones before closing the pool they depend on.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		logger.Error(fmt.Errorf("could not gracefully shutdown http server: %w", err))
+		exitCode = 1
+	}
+
+	// Stop the background workers before closing the pool they use, an
+	// interrupted batch is published or sent again by the next run.
+	stopWorkers()
+	<-relayDone
+	<-dispatchDone
+	<-purgeDone
+	<-purgeRateLimitsDone
+
+	if err := closePublisher(); err != nil {
+		logger.Error(fmt.Errorf("could not close outbox publisher: %w", err))
+		exitCode = 1
+	}
+
+	dbPool.Close()
+
+	// Flush spans with a timeout of their own, draining the http server
+	// may have used up the one of the shutdown.
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), cfg.Tracing.ShutdownTimeout)
+	defer cancelTracing()
+
+	if err := shutdownTracing(tracingCtx); err != nil {
 		logger.Error(err)
-		os.Exit(1)
+		exitCode = 1
+	}
+
+	logger.Info("shutdown complete")
+	logger.Sync()
+
+	if logFile != nil {
+		logFile.Close()
+	}
+
+	os.Exit(exitCode)
+}
+
+// prepareSchema checks the schema against the latest embedded migration, and
+// applies the pending migrations first if auto migration is enabled.
+func prepareSchema(cfg config.Config, dbPool *pgxpool.Pool, latest uint, logger *logging.Logger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Migrations.Timeout)
+	defer cancel()
+
+	if !cfg.Migrations.Auto {
+		return postgres.CheckSchema(ctx, dbPool, latest)
+	}
+
+	logger.Info("waiting for migration lock")
+
+	from, to, err := postgres.Migrate(ctx, dbPool, cfg.Postgres.ConnectionConfig().ConnString(), migrations.FS)
+	if err != nil {
+		return err
 	}
+
+	if from == to {
+		logger.InfoWith("schema is up to date", "version", to)
+		return nil
+	}
+
+	logger.InfoWith("migrated schema", "from", from, "to", to)
+	return nil
 }
 
-func getEnv(key string, fallback string) string {
-	val, ok := os.LookupEnv(key)
-	if !ok {
-		return fallback
+// newResilientRepository decorates repo with retries and a circuit breaker
+// that log and record their actions.
+func newResilientRepository(
+	cfg config.Resilience,
+	repo *postgres.ExampleRepository,
+	registry *prometheus.Registry,
+	logger *logging.Logger,
+) resilience.ExampleRepository {
+	const name = "example_repository"
+
+	m := metrics.NewResilienceMetrics(registry)
+
+	return resilience.ExampleRepository{
+		Repository: repo,
+		Retry: resilience.Retry{
+			MaxAttempts: cfg.RetryMaxAttempts,
+			BaseDelay:   cfg.RetryBaseDelay,
+			MaxDelay:    cfg.RetryMaxDelay,
+			OnRetry: func(attempt int, delay time.Duration, err error) {
+				m.Retried(name)
+				logger.InfoWith("retrying repository call", "attempt", attempt, "delay", delay.String(), "error", err.Error())
+			},
+		},
+		Breaker: &resilience.Breaker{
+			Name:             name,
+			FailureThreshold: cfg.BreakerThreshold,
+			OpenTimeout:      cfg.BreakerTimeout,
+			IsFailure:        resilience.IsFailure,
+			OnStateChange: func(name string, from, to resilience.State) {
+				m.StateChanged(name, from, to)
+
+				if to == resilience.StateOpen {
+					logger.ErrorWith("circuit breaker opened", "name", name, "from", from.String())
+					return
+				}
+				logger.InfoWith("circuit breaker state changed", "name", name, "from", from.String(), "to", to.String())
+			},
+		},
+		InTx: postgres.InTx,
 	}
+}
+
+// newAuthenticators sets up the configured authentication methods.
+func newAuthenticators(cfg config.Auth, apiKeys auth.APIKeyStore) ([]auth.Authenticator, error) {
+	var authenticators []auth.Authenticator
 
-	return val
+	if cfg.JWT() {
+		a, err := auth.NewJWTAuthenticator(auth.JWTOptions{
+			HS256Secret:  []byte(cfg.JWTHS256Secret),
+			RS256KeyFile: cfg.JWTRS256KeyFile,
+			JWKSFile:     cfg.JWTJWKSFile,
+			Issuer:       cfg.JWTIssuer,
+			Audience:     cfg.JWTAudience,
+			MaxLifetime:  cfg.JWTMaxLifetime,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not setup jwt authentication: %w", err)
+		}
+
+		authenticators = append(authenticators, a)
+	}
+
+	if cfg.APIKeys {
+		authenticators = append(authenticators, auth.APIKeyAuthenticator{Store: apiKeys})
+	}
+
+	if len(cfg.BasicCredentials) > 0 {
+		credentials, err := auth.ParseBasicCredentials(cfg.BasicCredentials)
+		if err != nil {
+			return nil, fmt.Errorf("could not setup basic authentication: %w", err)
+		}
+
+		roles, err := auth.ParseAssignments(cfg.BasicRoles)
+		if err != nil {
+			return nil, fmt.Errorf("could not setup basic authentication: %w", err)
+		}
+
+		authenticators = append(authenticators, auth.BasicAuthenticator{
+			Realm:       "Example",
+			Credentials: credentials,
+			Roles:       roles,
+		})
+	}
+
+	return authenticators, nil
+}
+
+// newPublisher sets up the configured publisher of outbox events, nil if
+// events are not relayed by this process. The returned func closes it.
+func newPublisher(cfg config.Outbox) (outbox.Publisher, func() error, error) {
+	noop := func() error { return nil }
+
+	switch cfg.Publisher {
+	case "stdout":
+		return &outbox.WriterPublisher{W: os.Stdout}, noop, nil
+	case "file":
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not open outbox file: %w", err)
+		}
+
+		return &outbox.WriterPublisher{W: f}, f.Close, nil
+	case "webhook":
+		return &outbox.WebhookPublisher{
+			URL:    cfg.WebhookURL,
+			Client: &http.Client{Timeout: 10 * time.Second},
+		}, noop, nil
+	default:
+		return nil, noop, nil
+	}
+}
+
+// listenOutbox notifies of new outbox events until ctx is done, listening
+// again after a failure. The relay keeps polling meanwhile.
+func listenOutbox(ctx context.Context, pool *pgxpool.Pool, notify chan<- struct{}, logger *logging.Logger) {
+	for {
+		err := postgres.Listen(ctx, pool, postgres.OutboxChannel, notify)
+		if err != nil {
+			logger.Error(fmt.Errorf("outbox notifications failed: %w", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
 }
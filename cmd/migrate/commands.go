@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v4"
+)
+
+var errUsage = errors.New("invalid usage")
+
+// cli runs the commands of the migrate binary.
+type cli struct {
+	dir    string
+	dbURL  string
+	stdin  io.Reader
+	stdout io.Writer
+
+	m *migrate.Migrate // Connected on first use.
+}
+
+func (c *cli) run(command string, args []string) error {
+	args, yes := parseYes(args)
+
+	switch command {
+	case "up":
+		return c.up(args)
+	case "down":
+		return c.down(args, yes)
+	case "goto":
+		return c.gotoVersion(args, yes)
+	case "version":
+		return c.version(args)
+	case "force":
+		return c.force(args)
+	case "status":
+		return c.status(args)
+	case "create":
+		return c.create(args)
+	case "lint":
+		return c.lint(args)
+	default:
+		return fmt.Errorf("unknown command %q: %w", command, errUsage)
+	}
+}
+
+func (c *cli) migrate() (*migrate.Migrate, error) {
+	if c.m != nil {
+		return c.m, nil
+	}
+
+	m, err := newMigrate(c.dir, c.dbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c.m = m
+	return m, nil
+}
+
+func (c *cli) up(args []string) error {
+	n, err := optionalCount(args)
+	if err != nil {
+		return err
+	}
+
+	m, err := c.migrate()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		log.Println("***applying all up migrations***")
+		err = m.Up()
+	} else {
+		log.Printf("***applying %d up migrations***", n)
+		err = m.Steps(n)
+	}
+
+	return c.done(err, "could not perform up migrations")
+}
+
+func (c *cli) down(args []string, yes bool) error {
+	n, err := optionalCount(args)
+	if err != nil {
+		return err
+	}
+
+	what := "all migrations"
+	if n > 0 {
+		what = fmt.Sprintf("%d migrations", n)
+	}
+
+	if !yes && !c.confirm(fmt.Sprintf("Revert %s? Data of reverted migrations may be lost.", what)) {
+		return errors.New("aborted")
+	}
+
+	m, err := c.migrate()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("***applying down migrations of %s***", what)
+	if n == 0 {
+		err = m.Down()
+	} else {
+		err = m.Steps(-n)
+	}
+
+	return c.done(err, "could not perform down migrations")
+}
+
+func (c *cli) gotoVersion(args []string, yes bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("goto requires a version: %w", errUsage)
+	}
+
+	target, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", args[0], errUsage)
+	}
+
+	m, err := c.migrate()
+	if err != nil {
+		return err
+	}
+
+	current, _, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("could not get version: %w", err)
+	}
+
+	if uint(target) < current && !yes &&
+		!c.confirm(fmt.Sprintf("Migrate down from version %d to %d? Data of reverted migrations may be lost.", current, target)) {
+		return errors.New("aborted")
+	}
+
+	log.Printf("***migrating to version %d***", target)
+
+	return c.done(m.Migrate(uint(target)), fmt.Sprintf("could not migrate to version %d", target))
+}
+
+func (c *cli) version(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("version takes no arguments: %w", errUsage)
+	}
+
+	m, err := c.migrate()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Fprintln(c.stdout, "no migrations applied")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not get version: %w", err)
+	}
+
+	if dirty {
+		fmt.Fprintf(c.stdout, "%d (dirty)\n", version)
+		return nil
+	}
+
+	fmt.Fprintln(c.stdout, version)
+	return nil
+}
+
+func (c *cli) force(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("force requires a version: %w", errUsage)
+	}
+
+	version, err := strconv.Atoi(args[0])
+	if err != nil || version < -1 {
+		return fmt.Errorf("invalid version %q: %w", args[0], errUsage)
+	}
+
+	m, err := c.migrate()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("***forcing version %d***", version)
+	if err := m.Force(version); err != nil {
+		return fmt.Errorf("could not force version %d: %w", version, err)
+	}
+
+	return nil
+}
+
+func (c *cli) status(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("status takes no arguments: %w", errUsage)
+	}
+
+	files, err := readMigrations(c.dir)
+	if err != nil {
+		return err
+	}
+
+	m, err := c.migrate()
+	if err != nil {
+		return err
+	}
+
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("could not get version: %w", err)
+	}
+
+	printStatus(c.stdout, files, current, dirty)
+	return nil
+}
+
+func (c *cli) create(args []string) error {
+	seq := false
+	if len(args) > 0 && (args[0] == "--seq" || args[0] == "-seq") {
+		seq, args = true, args[1:]
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("create requires a name: %w", errUsage)
+	}
+
+	up, down, err := createMigration(c.dir, strings.Join(args, "_"), seq, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout, up)
+	fmt.Fprintln(c.stdout, down)
+	return nil
+}
+
+func (c *cli) lint(args []string) error {
+	dryRunning := false
+	if len(args) > 0 && (args[0] == "--dry-run" || args[0] == "-dry-run") {
+		dryRunning, args = true, args[1:]
+	}
+
+	if len(args) != 0 {
+		return fmt.Errorf("lint takes no arguments: %w", errUsage)
+	}
+
+	findings, err := lintMigrations(c.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range findings {
+		fmt.Fprintln(c.stdout, f)
+	}
+
+	if dryRunning {
+		files, err := readMigrations(c.dir)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+
+		conn, err := pgx.Connect(ctx, c.dbURL)
+		if err != nil {
+			return fmt.Errorf("could not connect: %w", err)
+		}
+		defer conn.Close(ctx)
+
+		if err := dryRun(ctx, conn, c.dir, files, c.stdout); err != nil {
+			return fmt.Errorf("dry run failed: %w", err)
+		}
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("%d lint findings, fix or acknowledge them with a -- lint:ignore <rule> comment before the statement or -- lint:ignore-file <rule>", len(findings))
+	}
+
+	return nil
+}
+
+// done logs the outcome of a migration, no change is not a failure.
+func (c *cli) done(err error, msg string) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		log.Println("***no migrations to apply***")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+
+	log.Println("***done***")
+	return nil
+}
+
+// confirm asks the question and reports whether it was answered yes.
+func (c *cli) confirm(question string) bool {
+	fmt.Fprintf(c.stdout, "%s [y/N] ", question)
+
+	answer, _ := bufio.NewReader(c.stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+// parseYes removes the --yes flag from args, which may be given anywhere.
+func parseYes(args []string) ([]string, bool) {
+	var (
+		rest []string
+		yes  bool
+	)
+
+	for _, arg := range args {
+		switch arg {
+		case "--yes", "-yes", "-y":
+			yes = true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	return rest, yes
+}
+
+// optionalCount parses the optional number of migrations of up and down,
+// 0 if not given.
+func optionalCount(args []string) (int, error) {
+	switch len(args) {
+	case 0:
+		return 0, nil
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid number of migrations %q: %w", args[0], errUsage)
+		}
+
+		return n, nil
+	default:
+		return 0, fmt.Errorf("too many arguments: %w", errUsage)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/bratteby/go-service-template/internal/postgres"
+)
+
+// dryRunLockTimeout bounds waiting for the locks of the migrations, the dry
+// run must not queue up the traffic of the tables behind it.
+const dryRunLockTimeout = "5s"
+
+// concurrently marks statements that cannot run in a transaction.
+var concurrently = regexp.MustCompile(`(?i)^(CREATE\s+(UNIQUE\s+)?INDEX|DROP\s+INDEX|REINDEX)\b.*\bCONCURRENTLY\b`)
+
+// dryRun applies the up migrations of files pending on the database of conn
+// and then their down migrations in reverse, in a transaction that is
+// rolled back. It stops at the first failing migration, and before a
+// migration that cannot run in a transaction.
+func dryRun(ctx context.Context, conn *pgx.Conn, dir string, files []migrationFile, w io.Writer) (err error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rollbackErr := tx.Rollback(context.Background()); rollbackErr != nil && err == nil {
+			err = fmt.Errorf("could not roll back: %w", rollbackErr)
+			return
+		}
+
+		fmt.Fprintln(w, "rolled back, the database is unchanged")
+	}()
+
+	if _, err := tx.Exec(ctx, "SET LOCAL lock_timeout = '"+dryRunLockTimeout+"'"); err != nil {
+		return fmt.Errorf("could not set lock timeout: %w", err)
+	}
+
+	current, dirty, err := postgres.SchemaVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("schema is dirty, migration %d failed and must be fixed and forced", current)
+	}
+
+	var applied []migrationFile
+	for _, f := range files {
+		if f.Version <= current {
+			continue
+		}
+
+		ok, err := dryRunFile(ctx, tx, dir, f.Up, w)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		applied = append(applied, f)
+	}
+
+	if len(applied) == 0 {
+		fmt.Fprintf(w, "no pending migrations after version %d\n", current)
+		return nil
+	}
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		ok, err := dryRunFile(ctx, tx, dir, applied[i].Down, w)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+	}
+
+	return nil
+}
+
+// dryRunFile executes the migration file name in tx and reports whether
+// the dry run can go on.
+func dryRunFile(ctx context.Context, tx pgx.Tx, dir, name string, w io.Writer) (bool, error) {
+	if name == "" {
+		fmt.Fprintln(w, "stopped: migration file is missing")
+		return false, nil
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return false, fmt.Errorf("could not read migration: %w", err)
+	}
+
+	stmts, _ := splitStatements(string(b))
+	for _, s := range stmts {
+		if concurrently.MatchString(s.SQL) {
+			fmt.Fprintf(w, "%s: skipped, line %d cannot run in a transaction; stopped\n", name, s.Line)
+			return false, nil
+		}
+	}
+
+	// Executed as a whole like golang-migrate does.
+	if _, err := tx.Exec(ctx, string(b)); err != nil {
+		fmt.Fprintf(w, "%s: FAILED\n", name)
+		return false, fmt.Errorf("%s: %w", name, err)
+	}
+
+	fmt.Fprintf(w, "%s: ok\n", name)
+	return true, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+	invalidNameChars  = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// timestampFormat versions migrations created without --seq.
+const timestampFormat = "20060102150405"
+
+// migrationFile is a migration of the migrations directory.
+type migrationFile struct {
+	Version uint
+	Name    string
+	Up      string // File names, empty if missing.
+	Down    string
+}
+
+// readMigrations returns the migrations of dir ordered by version, files
+// not named <version>_<name>.(up|down).sql are ignored.
+func readMigrations(dir string) ([]migrationFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read migrations: %w", err)
+	}
+
+	byVersion := map[uint]*migrationFile{}
+	for _, e := range entries {
+		match := migrationFileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version of %s: %w", e.Name(), err)
+		}
+
+		f, ok := byVersion[uint(version)]
+		if !ok {
+			f = &migrationFile{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = f
+		}
+
+		if match[3] == "up" {
+			f.Up = e.Name()
+		} else {
+			f.Down = e.Name()
+		}
+	}
+
+	files := make([]migrationFile, 0, len(byVersion))
+	for _, f := range byVersion {
+		files = append(files, *f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+
+	return files, nil
+}
+
+// printStatus lists files as applied or pending given the current version
+// of the database, 0 if none.
+func printStatus(w io.Writer, files []migrationFile, current uint, dirty bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+
+	known := current == 0
+	for _, f := range files {
+		status := "pending"
+		switch {
+		case f.Version == current && dirty:
+			status = "dirty, fix and force a version"
+		case f.Version <= current:
+			status = "applied"
+		}
+
+		if f.Version == current {
+			known = true
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", f.Version, f.Name, status)
+	}
+
+	tw.Flush()
+
+	if !known {
+		fmt.Fprintf(w, "\ncurrent version %d has no migration file\n", current)
+	}
+}
+
+// createMigration creates empty up and down files of a migration named name
+// in dir and returns their paths. The version is the next sequence number
+// if seq, otherwise the timestamp now.
+func createMigration(dir, name string, seq bool, now time.Time) (string, string, error) {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("name must contain letters or digits: %w", errUsage)
+	}
+
+	files, err := readMigrations(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	version := now.UTC().Format(timestampFormat)
+	if seq {
+		var latest uint
+		if len(files) > 0 {
+			latest = files[len(files)-1].Version
+		}
+
+		version = fmt.Sprintf("%04d", latest+1)
+	}
+
+	for _, f := range files {
+		if strconv.FormatUint(uint64(f.Version), 10) == strings.TrimLeft(version, "0") {
+			return "", "", fmt.Errorf("migration version %s already exists", version)
+		}
+	}
+
+	var paths []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return "", "", fmt.Errorf("could not create migration: %w", err)
+		}
+		f.Close()
+
+		paths = append(paths, path)
+	}
+
+	return paths[0], paths[1], nil
+}
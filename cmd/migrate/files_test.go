@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, names ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+
+	return dir
+}
+
+func TestReadMigrations(t *testing.T) {
+	// Arrange
+	dir := writeFiles(t,
+		"0002_b.up.sql", "0002_b.down.sql",
+		"0001_a.up.sql", "0001_a.down.sql",
+		"0003_c.up.sql",
+		"init.sql", "README.md",
+	)
+
+	// Act
+	files, err := readMigrations(dir)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []migrationFile{
+		{Version: 1, Name: "a", Up: "0001_a.up.sql", Down: "0001_a.down.sql"},
+		{Version: 2, Name: "b", Up: "0002_b.up.sql", Down: "0002_b.down.sql"},
+		{Version: 3, Name: "c", Up: "0003_c.up.sql"},
+	}, files)
+}
+
+func TestPrintStatus(t *testing.T) {
+	files := []migrationFile{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}, {Version: 3, Name: "c"}}
+
+	tests := []struct {
+		name     string
+		current  uint
+		dirty    bool
+		expected string
+	}{
+		{
+			name:    "none applied",
+			current: 0,
+			expected: "VERSION  NAME  STATUS\n" +
+				"1        a     pending\n" +
+				"2        b     pending\n" +
+				"3        c     pending\n",
+		},
+		{
+			name:    "dirty",
+			current: 2,
+			dirty:   true,
+			expected: "VERSION  NAME  STATUS\n" +
+				"1        a     applied\n" +
+				"2        b     dirty, fix and force a version\n" +
+				"3        c     pending\n",
+		},
+		{
+			name:    "unknown version",
+			current: 4,
+			expected: "VERSION  NAME  STATUS\n" +
+				"1        a     applied\n" +
+				"2        b     applied\n" +
+				"3        c     applied\n" +
+				"\ncurrent version 4 has no migration file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			// Act
+			printStatus(&buf, files, tt.current, tt.dirty)
+
+			// Assert
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	now := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		givenName    string
+		seq          bool
+		expectedUp   string
+		expectedDown string
+		err          bool
+	}{
+		{
+			name:         "timestamped",
+			givenName:    "Add widget-table",
+			expectedUp:   "20230405060708_add_widget_table.up.sql",
+			expectedDown: "20230405060708_add_widget_table.down.sql",
+		},
+		{
+			name:         "sequential",
+			givenName:    "add_widget",
+			seq:          true,
+			expectedUp:   "0003_add_widget.up.sql",
+			expectedDown: "0003_add_widget.down.sql",
+		},
+		{
+			name:      "invalid name",
+			givenName: "--",
+			err:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			dir := writeFiles(t, "0001_a.up.sql", "0002_b.up.sql")
+
+			// Act
+			up, down, err := createMigration(dir, tt.givenName, tt.seq, now)
+
+			// Assert
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(dir, tt.expectedUp), up)
+			assert.Equal(t, filepath.Join(dir, tt.expectedDown), down)
+			assert.FileExists(t, up)
+			assert.FileExists(t, down)
+		})
+	}
+}
+
+func TestConfirmDown(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	c := &cli{stdin: bytes.NewBufferString("n\n"), stdout: &out}
+
+	// Act
+	err := c.run("down", []string{"1"})
+
+	// Assert
+	assert.EqualError(t, err, "aborted")
+	assert.Contains(t, out.String(), "Revert 1 migrations?")
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Lint rules, named in findings and in ignore comments.
+const (
+	ruleMissingDown        = "missing-down"
+	ruleMissingUp          = "missing-up"
+	ruleVersionGap         = "version-gap"
+	ruleIndexNotConcurrent = "index-not-concurrent"
+	ruleAlterColumnType    = "alter-column-type"
+	ruleDrop               = "drop"
+)
+
+// firstTimestampVersion is the lowest version of migrations versioned by
+// timestamp, which are not expected to be sequential.
+const firstTimestampVersion = 19700101000000
+
+var (
+	// ignoreComment acknowledges findings of the rules it lists for the
+	// statement it precedes, or with ignore-file for the whole migration.
+	ignoreComment = regexp.MustCompile(`^\s*lint:(ignore|ignore-file)\s+([\w\-, ]+)$`)
+	dollarTag     = regexp.MustCompile(`^\$(?:[A-Za-z_]\w*)?\$`)
+
+	createTable = regexp.MustCompile(`(?i)^CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`)
+	createIndex = regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:[\w"]+\s+)?ON\s+(?:ONLY\s+)?([\w."]+)`)
+	alterTable  = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)`)
+	alterType   = regexp.MustCompile(`(?i)\bALTER\s+(?:COLUMN\s+)?([\w"]+)\s+(?:SET\s+DATA\s+)?TYPE\b`)
+	dropColumn  = regexp.MustCompile(`(?i)\bDROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?([\w"]+)`)
+	addColumn   = regexp.MustCompile(`(?i)\bADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?([\w"]+)`)
+	drop        = regexp.MustCompile(`(?i)^DROP\s+(MATERIALIZED\s+VIEW|FOREIGN\s+TABLE|\w+)\s+(?:CONCURRENTLY\s+)?(?:IF\s+EXISTS\s+)?([\w."]+)`)
+	create      = regexp.MustCompile(`(?i)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:UNIQUE\s+)?(?:(?:GLOBAL|LOCAL)\s+)?(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?(MATERIALIZED\s+VIEW|FOREIGN\s+TABLE|\w+)\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`)
+)
+
+// notColumns follow DROP or ADD in ALTER TABLE actions that do not drop or
+// add a column.
+var notColumns = map[string]bool{
+	"constraint": true,
+	"default":    true,
+	"not":        true,
+	"identity":   true,
+	"expression": true,
+	"primary":    true,
+	"unique":     true,
+	"foreign":    true,
+	"check":      true,
+	"exclude":    true,
+}
+
+// finding is a problem of a migration file found by lintMigrations.
+type finding struct {
+	File    string
+	Line    int // 0 if the finding concerns the file as a whole.
+	Rule    string
+	Message string
+}
+
+func (f finding) String() string {
+	location := f.File
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+
+	return fmt.Sprintf("%s: %s (%s)", location, f.Message, f.Rule)
+}
+
+// statement is an SQL statement of a migration file.
+type statement struct {
+	Line   int    // Of the start of the statement.
+	SQL    string // Without comments and with whitespace collapsed.
+	Ignore []string
+}
+
+// lintMigrations checks the migrations of dir for missing files, version
+// gaps and statements that lock or destroy data of existing tables. Down
+// migrations may drop what their up migration creates.
+// Findings acknowledged by a "-- lint:ignore <rule>" comment before the
+// statement or a "-- lint:ignore-file <rule>" comment anywhere in the
+// migration are left out.
+func lintMigrations(dir string) ([]finding, error) {
+	files, err := readMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		findings []finding
+		previous uint
+	)
+
+	for _, f := range files {
+		var (
+			up, down             []statement
+			upIgnore, downIgnore []string
+		)
+		if f.Up != "" {
+			if up, upIgnore, err = readStatements(dir, f.Up); err != nil {
+				return nil, err
+			}
+		}
+		if f.Down != "" {
+			if down, downIgnore, err = readStatements(dir, f.Down); err != nil {
+				return nil, err
+			}
+		}
+
+		var fileFindings []finding
+		switch {
+		case f.Up == "":
+			fileFindings = append(fileFindings, finding{File: f.Down, Rule: ruleMissingUp, Message: "down migration without an up migration"})
+		case f.Down == "":
+			fileFindings = append(fileFindings, finding{File: f.Up, Rule: ruleMissingDown, Message: "up migration without a down migration"})
+		}
+
+		if f.Version < firstTimestampVersion && f.Version != previous+1 {
+			fileFindings = append(fileFindings, finding{
+				File:    firstNonEmpty(f.Up, f.Down),
+				Rule:    ruleVersionGap,
+				Message: fmt.Sprintf("version %d does not follow version %d", f.Version, previous),
+			})
+		}
+		previous = f.Version
+
+		findings = append(findings, withoutIgnored(fileFindings, append(upIgnore, downIgnore...))...)
+		findings = append(findings, withoutIgnored(lintStatements(f.Up, up, nil), upIgnore)...)
+		findings = append(findings, withoutIgnored(lintStatements(f.Down, down, createdObjects(up)), downIgnore)...)
+	}
+
+	return findings, nil
+}
+
+// lintStatements checks the statements of the migration file name. Dropping
+// the reverted objects, created by the up migration of a down migration, is
+// expected and not reported.
+func lintStatements(name string, stmts []statement, reverted map[string]bool) []finding {
+	var findings []finding
+
+	// Tables created by the migration are empty, locking them is harmless.
+	created := map[string]bool{}
+
+	for _, s := range stmts {
+		var found []finding
+		add := func(rule, format string, args ...any) {
+			found = append(found, finding{File: name, Line: s.Line, Rule: rule, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if match := createTable.FindStringSubmatch(s.SQL); match != nil {
+			created[identifier(match[1])] = true
+		}
+
+		if match := createIndex.FindStringSubmatch(s.SQL); match != nil && match[1] == "" && !created[identifier(match[2])] {
+			add(ruleIndexNotConcurrent, "CREATE INDEX blocks writes to existing table %s while building, use CREATE INDEX CONCURRENTLY as the only statement of a migration", identifier(match[2]))
+		}
+
+		if match := alterTable.FindStringSubmatch(s.SQL); match != nil {
+			table := identifier(match[1])
+			actions := s.SQL[len(match[0]):]
+
+			if !created[table] {
+				for _, m := range alterType.FindAllStringSubmatch(actions, -1) {
+					add(ruleAlterColumnType, "changing the type of column %s may rewrite table %s under an exclusive lock", identifier(m[1]), table)
+				}
+			}
+
+			for _, m := range dropColumn.FindAllStringSubmatch(actions, -1) {
+				if column := identifier(m[1]); !notColumns[column] && !reverted[objectKey("column", table+"."+column)] {
+					add(ruleDrop, "DROP COLUMN %s deletes its data of table %s", column, table)
+				}
+			}
+		}
+
+		if match := drop.FindStringSubmatch(s.SQL); match != nil && !reverted[objectKey(match[1], match[2])] {
+			kind := strings.ToUpper(strings.Join(strings.Fields(match[1]), " "))
+			add(ruleDrop, "DROP %s %s is destructive", kind, identifier(match[2]))
+		}
+
+		findings = append(findings, withoutIgnored(found, s.Ignore)...)
+	}
+
+	return findings
+}
+
+// createdObjects returns the keys of the objects and columns created by
+// stmts, see objectKey.
+func createdObjects(stmts []statement) map[string]bool {
+	created := map[string]bool{}
+	for _, s := range stmts {
+		if match := create.FindStringSubmatch(s.SQL); match != nil && !strings.EqualFold(match[2], "on") {
+			created[objectKey(match[1], match[2])] = true
+		}
+
+		if match := alterTable.FindStringSubmatch(s.SQL); match != nil {
+			table := identifier(match[1])
+			for _, m := range addColumn.FindAllStringSubmatch(s.SQL[len(match[0]):], -1) {
+				if column := identifier(m[1]); !notColumns[column] {
+					created[objectKey("column", table+"."+column)] = true
+				}
+			}
+		}
+	}
+
+	return created
+}
+
+// objectKey identifies an object of kind, such as TABLE, by its name.
+func objectKey(kind, name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(kind), " ")) + " " + identifier(name)
+}
+
+// withoutIgnored returns the findings whose rules are not in ignore.
+func withoutIgnored(findings []finding, ignore []string) []finding {
+	var kept []finding
+	for _, f := range findings {
+		if !contains(ignore, f.Rule) {
+			kept = append(kept, f)
+		}
+	}
+
+	return kept
+}
+
+func readStatements(dir, name string) ([]statement, []string, error) {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read migration: %w", err)
+	}
+
+	stmts, fileIgnore := splitStatements(string(b))
+	return stmts, fileIgnore, nil
+}
+
+// splitStatements splits sql into its statements, ignoring semicolons of
+// comments, quoted identifiers and string constants including dollar quoted
+// function bodies. The rules of lint:ignore-file comments are returned too.
+func splitStatements(sql string) ([]statement, []string) {
+	var (
+		stmts      []statement
+		current    strings.Builder
+		ignore     []string
+		fileIgnore []string
+		line       = 1
+		start      = 0 // Line of the current statement, 0 until it starts.
+	)
+
+	flush := func() {
+		if text := strings.Join(strings.Fields(current.String()), " "); text != "" {
+			stmts = append(stmts, statement{Line: start, SQL: text, Ignore: ignore})
+			ignore = nil
+		}
+
+		current.Reset()
+		start = 0
+	}
+
+	// write adds the text of the statement, which starts at its first
+	// non-space character.
+	write := func(text string) {
+		if start == 0 && strings.TrimSpace(text) != "" {
+			start = line
+		}
+
+		current.WriteString(text)
+		line += strings.Count(text, "\n")
+	}
+
+	for i := 0; i < len(sql); {
+		rest := sql[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+
+			if match := ignoreComment.FindStringSubmatch(rest[2:end]); match != nil {
+				rules := strings.FieldsFunc(match[2], func(r rune) bool { return r == ',' || r == ' ' })
+				if match[1] == "ignore-file" {
+					fileIgnore = append(fileIgnore, rules...)
+				} else {
+					ignore = append(ignore, rules...)
+				}
+			}
+
+			current.WriteByte(' ')
+			i += end
+
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+
+			line += strings.Count(rest[:end], "\n")
+			current.WriteByte(' ')
+			i += end
+
+		case rest[0] == '\'' || rest[0] == '"':
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2
+			}
+
+			write(rest[:end])
+			i += end
+
+		case rest[0] == '$' && dollarTag.MatchString(rest):
+			tag := dollarTag.FindString(rest)
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2 * len(tag)
+			}
+
+			write(rest[:end])
+			i += end
+
+		case rest[0] == ';':
+			flush()
+			i++
+
+		default:
+			write(rest[:1])
+			i++
+		}
+	}
+
+	flush()
+
+	return stmts, fileIgnore
+}
+
+// identifier normalizes the possibly quoted and schema qualified name of a
+// table or column.
+func identifier(name string) string {
+	name = strings.ReplaceAll(name, `"`, "")
+	name = strings.TrimPrefix(strings.ToLower(name), "public.")
+
+	return name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	// Arrange
+	sql := `-- lint:ignore-file drop
+CREATE TABLE t (
+    id INTEGER, -- no; split
+    note TEXT DEFAULT 'a;b' /* nor; here */
+);
+
+-- lint:ignore drop, alter-column-type
+DROP TABLE "odd;name";
+
+CREATE FUNCTION f() RETURNS TRIGGER AS $body$
+BEGIN
+    RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql`
+
+	// Act
+	stmts, fileIgnore := splitStatements(sql)
+
+	// Assert
+	assert.Equal(t, []string{"drop"}, fileIgnore)
+	assert.Equal(t, []statement{
+		{Line: 2, SQL: "CREATE TABLE t ( id INTEGER, note TEXT DEFAULT 'a;b' )"},
+		{Line: 8, SQL: `DROP TABLE "odd;name"`, Ignore: []string{"drop", "alter-column-type"}},
+		{Line: 10, SQL: "CREATE FUNCTION f() RETURNS TRIGGER AS $body$ BEGIN RETURN NEW; END; $body$ LANGUAGE plpgsql"},
+	}, stmts)
+}
+
+func TestLintMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []finding
+	}{
+		{
+			name: "clean",
+			files: map[string]string{
+				"0001_a.up.sql":   "CREATE TABLE a (id INT);\nCREATE INDEX a_idx ON a (id);",
+				"0001_a.down.sql": "DROP INDEX a_idx;\nDROP TABLE a;",
+				"0002_b.up.sql":   "CREATE INDEX CONCURRENTLY b_idx ON a (id);",
+				"0002_b.down.sql": "-- lint:ignore drop\nDROP INDEX CONCURRENTLY b_idx;",
+			},
+		},
+		{
+			name: "missing files and gaps",
+			files: map[string]string{
+				"0001_a.up.sql":             "SELECT 1;",
+				"0003_c.up.sql":             "SELECT 1;",
+				"0003_c.down.sql":           "SELECT 1;",
+				"0004_d.down.sql":           "SELECT 1;",
+				"20240101120000_e.up.sql":   "SELECT 1;",
+				"20240101120000_e.down.sql": "SELECT 1;",
+				"20240301120000_f.up.sql":   "-- lint:ignore-file missing-down\nSELECT 1;",
+			},
+			expected: []finding{
+				{File: "0001_a.up.sql", Rule: ruleMissingDown, Message: "up migration without a down migration"},
+				{File: "0003_c.up.sql", Rule: ruleVersionGap, Message: "version 3 does not follow version 1"},
+				{File: "0004_d.down.sql", Rule: ruleMissingUp, Message: "down migration without an up migration"},
+			},
+		},
+		{
+			name: "down migrations dropping what their up migration did not create",
+			files: map[string]string{
+				"0001_a.up.sql": "CREATE TABLE a (id INT);\n" +
+					"ALTER TABLE a ADD COLUMN name TEXT, ADD CONSTRAINT a_pkey PRIMARY KEY (id);\n" +
+					"CREATE OR REPLACE FUNCTION f() RETURNS INT AS $$ SELECT 1 $$ LANGUAGE sql;",
+				"0001_a.down.sql": "DROP FUNCTION f;\n" +
+					"ALTER TABLE a DROP COLUMN name, DROP COLUMN note;\n" +
+					"DROP TABLE b;\n" +
+					"DROP TABLE a;",
+			},
+			expected: []finding{
+				{File: "0001_a.down.sql", Line: 2, Rule: ruleDrop, Message: "DROP COLUMN note deletes its data of table a"},
+				{File: "0001_a.down.sql", Line: 3, Rule: ruleDrop, Message: "DROP TABLE b is destructive"},
+			},
+		},
+		{
+			name: "risky statements",
+			files: map[string]string{
+				"0001_a.up.sql": "CREATE UNIQUE INDEX a_idx ON public.a (id);\n" +
+					"ALTER TABLE a ALTER COLUMN id TYPE BIGINT, ALTER name SET DATA TYPE TEXT;\n" +
+					"ALTER TABLE a DROP CONSTRAINT a_pkey, ALTER COLUMN name DROP NOT NULL, DROP COLUMN IF EXISTS note;\n" +
+					"CREATE TABLE b (id INT);\n" +
+					"CREATE INDEX ON b (id);\n" +
+					"ALTER TABLE b ALTER COLUMN id TYPE BIGINT;",
+				"0001_a.down.sql": "DROP MATERIALIZED VIEW IF EXISTS v;",
+			},
+			expected: []finding{
+				{File: "0001_a.up.sql", Line: 1, Rule: ruleIndexNotConcurrent, Message: "CREATE INDEX blocks writes to existing table a while building, use CREATE INDEX CONCURRENTLY as the only statement of a migration"},
+				{File: "0001_a.up.sql", Line: 2, Rule: ruleAlterColumnType, Message: "changing the type of column id may rewrite table a under an exclusive lock"},
+				{File: "0001_a.up.sql", Line: 2, Rule: ruleAlterColumnType, Message: "changing the type of column name may rewrite table a under an exclusive lock"},
+				{File: "0001_a.up.sql", Line: 3, Rule: ruleDrop, Message: "DROP COLUMN note deletes its data of table a"},
+				{File: "0001_a.down.sql", Line: 1, Rule: ruleDrop, Message: "DROP MATERIALIZED VIEW v is destructive"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+			}
+
+			// Act
+			findings, err := lintMigrations(dir)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, findings)
+		})
+	}
+}
+
+func TestLintMigrations_Repository(t *testing.T) {
+	// Act
+	findings, err := lintMigrations(filepath.Join("..", "..", "migrations"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, findings, "fix or acknowledge the findings of `migrate lint`")
+}
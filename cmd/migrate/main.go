@@ -7,80 +7,74 @@ import (
 	"log"
 	"os"
 
+	"github.com/bratteby/go-service-template/internal/config"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
-func migrateUp(m *migrate.Migrate) {
-	log.Println("***applying all up migrations***")
+const usage = `Usage: migrate [flags] <command> [arguments]
+
+Commands:
+  up [N]            apply all or N pending migrations
+  down [N] [--yes]  revert all or N applied migrations, after confirmation
+  goto V [--yes]    migrate up or down to version V, confirming a down
+  version           print the current version
+  force V           set the version to V without migrating, to recover
+                    from a failed migration; -1 means no version
+  status            list applied and pending migrations
+  create [--seq] NAME
+                    create an up and down migration named NAME, versioned
+                    by timestamp or sequentially with --seq
+  lint [--dry-run]  check the migration files for missing files, version
+                    gaps and statements that lock or drop data; with
+                    --dry-run also apply the pending migrations up and
+                    down in a transaction that is rolled back
+
+Run with -h for the flags.
+`
 
-	if err := m.Up(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			log.Println("***no new migrations to apply***")
-			os.Exit(0)
-		}
-
-		log.Fatal(fmt.Errorf("could not perform up migrations: %w", err))
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
 	}
-}
-
-func migrateDown(m *migrate.Migrate) {
-	log.Println("***applying all down migrations***")
 
-	if err := m.Down(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			log.Println("***no new migrations to apply***")
-			os.Exit(0)
-		}
-
-		log.Fatal(fmt.Errorf("could not perform up migrations: %w", err))
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], config.ScopeDatabase)
+	if err != nil {
+		log.Fatal(err)
 	}
-}
 
-func getEnv(key string, fallback string) string {
-	val, ok := os.LookupEnv(key)
-	if !ok {
-		return fallback
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	return val
-}
-
-func main() {
-	var (
-		host     = getEnv("POSTGRES_HOST", "localhost")
-		port     = getEnv("POSTGRES_PORT", "5432")
-		database = getEnv("POSTGRES_DB", "example")
-		user     = getEnv("POSTGRES_USER", "postgres")
-		password = getEnv("POSTGRES_PASSWORD", "")
-		ssl      = getEnv("POSTGRES_SSL", "disable")
+	c := &cli{
+		dir:    cfg.Migrations.Path,
+		dbURL:  cfg.Postgres.ConnectionConfig().ConnString(),
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+	}
 
-		migrationsFilePath = getEnv("MIGRATIONS_PATH", "migrations")
-	)
+	if err := c.run(args[0], args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "%s\n\n%s", err, usage)
+			os.Exit(2)
+		}
 
-	connString := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		user, password, host, port, database, ssl,
-	)
+		log.Fatal(err)
+	}
+}
 
-	m, err := migrate.New(
-		"file://"+migrationsFilePath,
-		connString,
-	)
+// newMigrate connects to the database to migrate it with the files of dir.
+func newMigrate(dir, dbURL string) (*migrate.Migrate, error) {
+	m, err := migrate.New("file://"+dir, dbURL)
 	if err != nil {
-		log.Fatal(fmt.Errorf("could not create migrate instance: %w", err))
+		return nil, fmt.Errorf("could not create migrate instance: %w", err)
 	}
 
-	mode := flag.String("m", "up", "migrate up or down [up, down]")
-	flag.Parse()
-
-	switch *mode {
-	case "up":
-		migrateUp(m)
-	case "down":
-		migrateDown(m)
-	default:
-		log.Fatal(fmt.Errorf("invalid mode '%s'", *mode))
-	}
+	return m, nil
 }
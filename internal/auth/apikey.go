@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyHeader is the request header carrying API keys.
+const APIKeyHeader = "X-API-Key"
+
+// APIKey is a stored API key, only the hash of the key itself is stored.
+type APIKey struct {
+	ID     uuid.UUID
+	Name   string
+	Scopes []string
+}
+
+// APIKeyStore looks up API keys.
+type APIKeyStore interface {
+	// FindAPIKeyByHash returns the active API key with the given hash,
+	// ErrInvalidCredentials if there is none.
+	FindAPIKeyByHash(ctx context.Context, hash string) (APIKey, error)
+}
+
+// APIKeyAuthenticator authenticates requests by an API key in APIKeyHeader.
+type APIKeyAuthenticator struct {
+	Store APIKeyStore
+}
+
+func (a APIKeyAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	apiKey, err := a.Store.FindAPIKeyByHash(r.Context(), HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			return Principal{}, fmt.Errorf("unknown api key: %w", err)
+		}
+
+		return Principal{}, fmt.Errorf("could not look up api key: %w", err)
+	}
+
+	return Principal{
+		Subject: apiKey.ID.String(),
+		Method:  MethodAPIKey,
+		Scopes:  apiKey.Scopes,
+	}, nil
+}
+
+// HashAPIKey returns the hash an API key is stored by. API keys are random
+// with high entropy so a fast unsalted hash is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAPIKey returns a new random API key and its hash.
+func GenerateAPIKey() (key string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("could not generate api key: %w", err)
+	}
+
+	key = base64.RawURLEncoding.EncodeToString(b)
+
+	return key, HashAPIKey(key), nil
+}
@@ -0,0 +1,121 @@
+// Package auth authenticates http requests and places the authenticated
+// Principal in the request context.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request
+	// carries no credentials it handles, so the next one may be tried.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned when credentials are present but
+	// could not be verified.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Method is the authentication method a principal was authenticated with.
+type Method string
+
+const (
+	MethodJWT    Method = "jwt"
+	MethodAPIKey Method = "api_key"
+	MethodBasic  Method = "basic"
+)
+
+// Principal is an authenticated client.
+type Principal struct {
+	Subject string // Identifies the client, e.g. a user name or API key id.
+	Method  Method
+	Roles   []string
+	// Scopes granted to the principal, after authentication by Middleware
+	// these include the scopes granted by its roles.
+	Scopes []string
+}
+
+// ID identifies the principal across authentication methods, as the same
+// subject authenticated by different methods may be different clients. It
+// is what records owned by the principal are stored with.
+func (p Principal) ID() string {
+	return string(p.Method) + ":" + p.Subject
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of ctx, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Authenticator authenticates a request.
+type Authenticator interface {
+	// Authenticate returns the principal of the request, ErrNoCredentials
+	// if the request carries no credentials for this authenticator.
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// challenger is implemented by authenticators that want to advertise their
+// scheme through WWW-Authenticate on failure.
+type challenger interface {
+	Challenge() string
+}
+
+// ErrorHandler writes the response of a failed request.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware authenticates requests by trying the authenticators in order,
+// the first one finding credentials decides the outcome. On success the
+// principal, with the scopes granted by policy, is placed in the request
+// context and its subject and method in the logged fields of the context,
+// otherwise onError is called with an error wrapping
+// ErrNoCredentials or ErrInvalidCredentials.
+func Middleware(policy Policy, onError ErrorHandler, authenticators ...Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			err := ErrNoCredentials
+
+			for _, a := range authenticators {
+				var p Principal
+
+				p, err = a.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					break
+				}
+
+				p.Scopes = policy.Scopes(p)
+
+				ctx := logging.WithFields(WithPrincipal(r.Context(), p),
+					logging.String("principal", p.Subject),
+					logging.String("authMethod", string(p.Method)),
+				)
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			for _, a := range authenticators {
+				if c, ok := a.(challenger); ok {
+					w.Header().Add("WWW-Authenticate", c.Challenge())
+				}
+			}
+
+			onError(w, r, err)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+type apiKeyStoreFunc func(ctx context.Context, hash string) (APIKey, error)
+
+func (f apiKeyStoreFunc) FindAPIKeyByHash(ctx context.Context, hash string) (APIKey, error) {
+	return f(ctx, hash)
+}
+
+func TestMiddleware(t *testing.T) {
+	// Arrange.
+	key, hash, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	keyID := uuid.New()
+	authenticators := []Authenticator{
+		APIKeyAuthenticator{
+			Store: apiKeyStoreFunc(func(ctx context.Context, h string) (APIKey, error) {
+				if h != hash {
+					return APIKey{}, ErrInvalidCredentials
+				}
+
+				return APIKey{ID: keyID, Name: "test", Scopes: []string{"example:read"}}, nil
+			}),
+		},
+		BasicAuthenticator{
+			Realm:       "Test",
+			Credentials: map[string]string{"user": "password"},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		setup             func(r *http.Request)
+		expectedPrincipal Principal
+		expectedError     error
+	}{
+		{
+			name:          "should fail without credentials",
+			setup:         func(r *http.Request) {},
+			expectedError: ErrNoCredentials,
+		},
+		{
+			name:          "should fail on wrong basic auth password",
+			setup:         func(r *http.Request) { r.SetBasicAuth("user", "wrong") },
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "should fail on unknown basic auth user",
+			setup:         func(r *http.Request) { r.SetBasicAuth("unknown", "password") },
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "should fail on unknown api key",
+			setup:         func(r *http.Request) { r.Header.Set(APIKeyHeader, "unknown") },
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:              "should authenticate basic auth",
+			setup:             func(r *http.Request) { r.SetBasicAuth("user", "password") },
+			expectedPrincipal: Principal{Subject: "user", Method: MethodBasic},
+		},
+		{
+			name:  "should authenticate api key",
+			setup: func(r *http.Request) { r.Header.Set(APIKeyHeader, key) },
+			expectedPrincipal: Principal{
+				Subject: keyID.String(),
+				Method:  MethodAPIKey,
+				Scopes:  []string{"example:read"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotPrincipal Principal
+				gotFields    []logging.Field
+				gotError     error
+			)
+
+			onError := func(w http.ResponseWriter, r *http.Request, err error) {
+				gotError = err
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+
+			handler := Middleware(Policy{}, onError, authenticators...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPrincipal, _ = FromContext(r.Context())
+				gotFields = logging.ContextFields(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.setup(r)
+			w := httptest.NewRecorder()
+
+			// Act.
+			handler.ServeHTTP(w, r)
+
+			// Assert.
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, gotError, tt.expectedError)
+				assert.Equal(t, `Basic realm="Test"`, w.Header().Get("WWW-Authenticate"))
+				return
+			}
+
+			require.NoError(t, gotError)
+			assert.Equal(t, tt.expectedPrincipal, gotPrincipal)
+			assert.Equal(t, []logging.Field{
+				logging.String("principal", tt.expectedPrincipal.Subject),
+				logging.String("authMethod", string(tt.expectedPrincipal.Method)),
+			}, gotFields)
+		})
+	}
+}
+
+func TestMiddlewareStoreError(t *testing.T) {
+	// Arrange.
+	storeErr := errors.New("database down")
+
+	var gotError error
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		gotError = err
+	}
+
+	handler := Middleware(Policy{}, onError, APIKeyAuthenticator{
+		Store: apiKeyStoreFunc(func(ctx context.Context, hash string) (APIKey, error) {
+			return APIKey{}, storeErr
+		}),
+	})(http.NotFoundHandler())
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(APIKeyHeader, "key")
+
+	// Act.
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Assert.
+	assert.ErrorIs(t, gotError, storeErr)
+	assert.NotErrorIs(t, gotError, ErrInvalidCredentials)
+}
+
+func TestParseBasicCredentials(t *testing.T) {
+	// Act.
+	credentials, err := ParseBasicCredentials([]string{"a:1", "b:with:colon"})
+	_, invalidErr := ParseBasicCredentials([]string{"missing-password"})
+
+	// Assert.
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "with:colon"}, credentials)
+	assert.Error(t, invalidErr)
+}
@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JWTOptions configures a JWTAuthenticator, at least one key is required.
+type JWTOptions struct {
+	HS256Secret  []byte        // Shared secret for HS256 signed tokens.
+	RS256KeyFile string        // PEM encoded RSA public key for RS256 signed tokens without key id.
+	JWKSFile     string        // JSON Web Key Set file with RSA public keys for RS256 signed tokens by key id.
+	Issuer       string        // Required iss claim, empty to not verify.
+	Audience     string        // Required aud claim, empty to not verify.
+	MaxLifetime  time.Duration // Maximum time between the iat and exp claims, both then required. Zero only requires exp.
+}
+
+// JWTAuthenticator authenticates requests by a bearer JWT signed with HS256
+// or RS256.
+type JWTAuthenticator struct {
+	hmacSecret  []byte
+	rsaKey      *rsa.PublicKey            // Used for tokens without key id.
+	rsaKeys     map[string]*rsa.PublicKey // By key id.
+	issuer      string
+	audience    string
+	maxLifetime time.Duration
+	parser      *jwt.Parser
+}
+
+// jwtClaims are the verified claims, scopes follow the OAuth 2.0 space
+// separated scope claim.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope"`
+	Roles []string `json:"roles"`
+}
+
+func NewJWTAuthenticator(opts JWTOptions) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{
+		hmacSecret:  opts.HS256Secret,
+		rsaKeys:     map[string]*rsa.PublicKey{},
+		issuer:      opts.Issuer,
+		audience:    opts.Audience,
+		maxLifetime: opts.MaxLifetime,
+	}
+
+	var methods []string
+	if len(opts.HS256Secret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	if opts.RS256KeyFile != "" {
+		b, err := os.ReadFile(opts.RS256KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read rsa key file: %w", err)
+		}
+
+		key, err := jwt.ParseRSAPublicKeyFromPEM(b)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse rsa key file %s: %w", opts.RS256KeyFile, err)
+		}
+
+		a.rsaKey = key
+	}
+
+	if opts.JWKSFile != "" {
+		keys, err := loadJWKS(opts.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for kid, key := range keys {
+			a.rsaKeys[kid] = key
+		}
+	}
+
+	if a.rsaKey != nil || len(a.rsaKeys) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no jwt keys configured")
+	}
+
+	// Restricting the methods guards against algorithm confusion, e.g. an RSA
+	// public key being used as HMAC secret.
+	a.parser = jwt.NewParser(jwt.WithValidMethods(methods))
+
+	return a, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return Principal{}, ErrNoCredentials
+	}
+
+	var claims jwtClaims
+	if _, err := a.parser.ParseWithClaims(token, &claims, a.key); err != nil {
+		return Principal{}, fmt.Errorf("invalid jwt: %v: %w", err, ErrInvalidCredentials)
+	}
+
+	if a.issuer != "" && !claims.VerifyIssuer(a.issuer, true) {
+		return Principal{}, fmt.Errorf("invalid jwt issuer: %w", ErrInvalidCredentials)
+	}
+
+	if a.audience != "" && !claims.VerifyAudience(a.audience, true) {
+		return Principal{}, fmt.Errorf("invalid jwt audience: %w", ErrInvalidCredentials)
+	}
+
+	// The parser only verifies exp if present, a token without it would be
+	// valid forever.
+	if claims.ExpiresAt == nil {
+		return Principal{}, fmt.Errorf("jwt without expiry: %w", ErrInvalidCredentials)
+	}
+
+	if a.maxLifetime > 0 {
+		if claims.IssuedAt == nil {
+			return Principal{}, fmt.Errorf("jwt without issue time: %w", ErrInvalidCredentials)
+		}
+
+		if lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time); lifetime > a.maxLifetime {
+			return Principal{}, fmt.Errorf("jwt lifetime %s exceeds %s: %w", lifetime, a.maxLifetime, ErrInvalidCredentials)
+		}
+	}
+
+	if claims.Subject == "" {
+		return Principal{}, fmt.Errorf("jwt without subject: %w", ErrInvalidCredentials)
+	}
+
+	return Principal{
+		Subject: claims.Subject,
+		Method:  MethodJWT,
+		Roles:   claims.Roles,
+		Scopes:  strings.Fields(claims.Scope),
+	}, nil
+}
+
+func (a *JWTAuthenticator) Challenge() string {
+	return "Bearer"
+}
+
+// key returns the verification key of a token.
+func (a *JWTAuthenticator) key(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.hmacSecret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			if a.rsaKey == nil {
+				return nil, fmt.Errorf("key id required")
+			}
+
+			return a.rsaKey, nil
+		}
+
+		key, ok := a.rsaKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
+
+// jwks is a JSON Web Key Set, see RFC 7517.
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// loadJWKS loads the RSA signing keys of a JSON Web Key Set file by key id.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read jwks file: %w", err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("could not decode jwks file %s: %w", path, err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		if k.Kid == "" {
+			return nil, fmt.Errorf("jwks key without key id in %s", path)
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus of jwks key %s: %w", k.Kid, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent of jwks key %s: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTAuthenticator(t *testing.T) {
+	// Arrange.
+	var (
+		dir      = t.TempDir()
+		secret   = []byte("secret")
+		now      = time.Now()
+		static   = newRSAKey(t)
+		rotating = newRSAKey(t)
+		other    = newRSAKey(t)
+	)
+
+	a, err := NewJWTAuthenticator(JWTOptions{
+		HS256Secret:  secret,
+		RS256KeyFile: writePublicKeyPEM(t, dir, static),
+		JWKSFile:     writeJWKS(t, dir, "rotating", rotating),
+		Issuer:       "issuer",
+		Audience:     "example",
+	})
+	require.NoError(t, err)
+
+	validClaims := jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "subject",
+			Issuer:    "issuer",
+			Audience:  jwt.ClaimStrings{"example"},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+		Scope: "example:read example:write",
+	}
+
+	withClaims := func(f func(c *jwtClaims)) jwtClaims {
+		c := validClaims
+		f(&c)
+		return c
+	}
+
+	tests := []struct {
+		name          string
+		token         string
+		expectedError error
+	}{
+		{
+			name:  "should accept HS256",
+			token: sign(t, jwt.SigningMethodHS256, "", secret, validClaims),
+		},
+		{
+			name:  "should accept RS256 with static key",
+			token: sign(t, jwt.SigningMethodRS256, "", static, validClaims),
+		},
+		{
+			name:  "should accept RS256 with jwks key",
+			token: sign(t, jwt.SigningMethodRS256, "rotating", rotating, validClaims),
+		},
+		{
+			name:          "should reject unknown key id",
+			token:         sign(t, jwt.SigningMethodRS256, "unknown", rotating, validClaims),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "should reject wrong key",
+			token:         sign(t, jwt.SigningMethodRS256, "", other, validClaims),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "should reject wrong secret",
+			token:         sign(t, jwt.SigningMethodHS256, "", []byte("wrong"), validClaims),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "should reject unsupported method",
+			token:         sign(t, jwt.SigningMethodHS512, "", secret, validClaims),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name: "should reject expired token",
+			token: sign(t, jwt.SigningMethodHS256, "", secret, withClaims(func(c *jwtClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			})),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name: "should reject token without expiry",
+			token: sign(t, jwt.SigningMethodHS256, "", secret, withClaims(func(c *jwtClaims) {
+				c.ExpiresAt = nil
+			})),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name: "should reject wrong issuer",
+			token: sign(t, jwt.SigningMethodHS256, "", secret, withClaims(func(c *jwtClaims) {
+				c.Issuer = "other"
+			})),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name: "should reject wrong audience",
+			token: sign(t, jwt.SigningMethodHS256, "", secret, withClaims(func(c *jwtClaims) {
+				c.Audience = jwt.ClaimStrings{"other"}
+			})),
+			expectedError: ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+
+			// Act.
+			p, err := a.Authenticate(r)
+
+			// Assert.
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Principal{
+				Subject: "subject",
+				Method:  MethodJWT,
+				Scopes:  []string{"example:read", "example:write"},
+			}, p)
+		})
+	}
+}
+
+func TestJWTAuthenticatorMaxLifetime(t *testing.T) {
+	// Arrange.
+	secret := []byte("secret")
+	now := time.Now()
+
+	a, err := NewJWTAuthenticator(JWTOptions{HS256Secret: secret, MaxLifetime: time.Hour})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		issuedAt      *jwt.NumericDate
+		expiresAt     *jwt.NumericDate
+		expectedError error
+	}{
+		{
+			name:      "should accept lifetime within bound",
+			issuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			expiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+		{
+			name:          "should reject lifetime beyond bound",
+			issuedAt:      jwt.NewNumericDate(now.Add(-time.Minute)),
+			expiresAt:     jwt.NewNumericDate(now.Add(24 * time.Hour)),
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "should reject token without issue time",
+			expiresAt:     jwt.NewNumericDate(now.Add(time.Minute)),
+			expectedError: ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := sign(t, jwt.SigningMethodHS256, "", secret, jwtClaims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   "subject",
+					IssuedAt:  tt.issuedAt,
+					ExpiresAt: tt.expiresAt,
+				},
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+token)
+
+			// Act.
+			_, err := a.Authenticate(r)
+
+			// Assert.
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestJWTAuthenticatorWithoutBearer(t *testing.T) {
+	// Arrange.
+	a, err := NewJWTAuthenticator(JWTOptions{HS256Secret: []byte("secret")})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("user", "password")
+
+	// Act.
+	_, err = a.Authenticate(r)
+
+	// Assert.
+	assert.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return key
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwtClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return s
+}
+
+func writePublicKeyPEM(t *testing.T, dir string, key *rsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	return path
+}
+
+func writeJWKS(t *testing.T, dir string, kid string, key *rsa.PrivateKey) string {
+	b, err := json.Marshal(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "jwks.json")
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	return path
+}
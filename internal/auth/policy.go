@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrForbidden is returned when an authenticated principal lacks a scope.
+var ErrForbidden = errors.New("insufficient scope")
+
+// Policy grants scopes to principals through their roles.
+type Policy struct {
+	Roles map[string][]string // Role to granted scopes.
+}
+
+// Scopes returns the effective scopes of p, its own scopes and those granted
+// by its roles.
+func (pol Policy) Scopes(p Principal) []string {
+	seen := map[string]bool{}
+	var scopes []string
+
+	add := func(s ...string) {
+		for _, scope := range s {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	add(p.Scopes...)
+	for _, role := range p.Roles {
+		add(pol.Roles[role]...)
+	}
+
+	return scopes
+}
+
+// HasScope reports whether the principal has been granted scope.
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequireScopes is a middleware only letting through requests whose
+// principal has all of the given scopes, otherwise onError is called with
+// an error wrapping ErrForbidden, or ErrNoCredentials if the request isn't
+// authenticated.
+func RequireScopes(onError ErrorHandler, scopes ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			p, ok := FromContext(r.Context())
+			if !ok {
+				onError(w, r, ErrNoCredentials)
+				return
+			}
+
+			for _, scope := range scopes {
+				if !p.HasScope(scope) {
+					onError(w, r, fmt.Errorf("%s requires scope %s: %w", p.Subject, scope, ErrForbidden))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ParseAssignments parses "name=value value" entries, e.g. role to scopes
+// or user to roles, into a map. A name may be given more than once.
+func ParseAssignments(entries []string) (map[string][]string, error) {
+	assignments := map[string][]string{}
+	for _, entry := range entries {
+		name, values, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q must be formatted as name=value [value...]", entry)
+		}
+
+		assignments[name] = append(assignments[name], strings.Fields(values)...)
+	}
+
+	return assignments, nil
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyScopes(t *testing.T) {
+	// Arrange.
+	policy := Policy{
+		Roles: map[string][]string{
+			"reader": {"example:read"},
+			"writer": {"example:read", "example:write"},
+		},
+	}
+
+	p := Principal{
+		Roles:  []string{"reader", "writer", "unknown"},
+		Scopes: []string{"own:scope"},
+	}
+
+	// Act.
+	scopes := policy.Scopes(p)
+
+	// Assert.
+	assert.Equal(t, []string{"own:scope", "example:read", "example:write"}, scopes)
+}
+
+func TestMiddlewareGrantsRoleScopes(t *testing.T) {
+	// Arrange.
+	policy := Policy{Roles: map[string][]string{"writer": {"example:write"}}}
+	basic := BasicAuthenticator{
+		Credentials: map[string]string{"user": "password"},
+		Roles:       map[string][]string{"user": {"writer"}},
+	}
+
+	var got Principal
+	handler := Middleware(policy, nil, basic)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("user", "password")
+
+	// Act.
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Assert.
+	assert.True(t, got.HasScope("example:write"))
+}
+
+func TestRequireScopes(t *testing.T) {
+	tests := []struct {
+		name          string
+		principal     *Principal
+		expectedError error
+	}{
+		{
+			name:          "should fail without principal",
+			expectedError: ErrNoCredentials,
+		},
+		{
+			name:          "should fail on missing scope",
+			principal:     &Principal{Subject: "user", Scopes: []string{"example:read"}},
+			expectedError: ErrForbidden,
+		},
+		{
+			name:      "should pass with all scopes",
+			principal: &Principal{Subject: "user", Scopes: []string{"example:read", "example:write"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange.
+			var (
+				gotError error
+				called   bool
+			)
+
+			onError := func(w http.ResponseWriter, r *http.Request, err error) {
+				gotError = err
+			}
+
+			handler := RequireScopes(onError, "example:read", "example:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.principal != nil {
+				r = r.WithContext(WithPrincipal(r.Context(), *tt.principal))
+			}
+
+			// Act.
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			// Assert.
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, gotError, tt.expectedError)
+				assert.False(t, called)
+				return
+			}
+
+			require.NoError(t, gotError)
+			assert.True(t, called)
+		})
+	}
+}
+
+func TestParseAssignments(t *testing.T) {
+	// Act.
+	got, err := ParseAssignments([]string{"writer=example:read example:write", "writer=extra", "empty="})
+	_, invalidErr := ParseAssignments([]string{"no-equals"})
+
+	// Assert.
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"writer": {"example:read", "example:write", "extra"},
+		"empty":  nil,
+	}, got)
+	assert.Error(t, invalidErr)
+}
@@ -0,0 +1,617 @@
+// Package config loads the typed service configuration from defaults, an
+// optional YAML/JSON file, environment variables and command-line flags.
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/logging"
+	"github.com/bratteby/go-service-template/internal/postgres"
+	"github.com/bratteby/go-service-template/internal/ratelimit"
+	"github.com/bratteby/go-service-template/internal/tracing"
+)
+
+// Config is the complete configuration of the service binaries.
+//
+// Every setting is described through struct tags:
+//   - yaml:    key in the configuration file.
+//   - env:     environment variable name.
+//   - flag:    command-line flag name.
+//   - default: value used when the setting is not provided anywhere.
+//   - secret:  the value is redacted when the configuration is printed.
+type Config struct {
+	HTTP        HTTP        `yaml:"http"`
+	Postgres    Postgres    `yaml:"postgres"`
+	Log         Log         `yaml:"log"`
+	Migrations  Migrations  `yaml:"migrations"`
+	Auth        Auth        `yaml:"auth"`
+	Metrics     Metrics     `yaml:"metrics"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Health      Health      `yaml:"health"`
+	Outbox      Outbox      `yaml:"outbox"`
+	Webhooks    Webhooks    `yaml:"webhooks"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	RateLimit   RateLimit   `yaml:"rateLimit"`
+	Resilience  Resilience  `yaml:"resilience"`
+}
+
+// HTTP configures the http server.
+type HTTP struct {
+	Address           string        `yaml:"address" env:"HTTP_ADDRESS" flag:"http-address" default:":80" usage:"address the http server listens on"`
+	AdminAddress      string        `yaml:"adminAddress" env:"HTTP_ADMIN_ADDRESS" flag:"http-admin-address" usage:"address of a separate listener for admin endpoints such as metrics, empty to serve them on the http address"`
+	ReadTimeout       time.Duration `yaml:"readTimeout" env:"HTTP_READ_TIMEOUT" flag:"http-read-timeout" default:"10s" usage:"maximum duration for reading an entire request"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" env:"HTTP_READ_HEADER_TIMEOUT" flag:"http-read-header-timeout" default:"5s" usage:"maximum duration for reading request headers"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout" env:"HTTP_WRITE_TIMEOUT" flag:"http-write-timeout" default:"30s" usage:"maximum duration before timing out writes of a response"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout" env:"HTTP_IDLE_TIMEOUT" flag:"http-idle-timeout" default:"120s" usage:"maximum duration to wait for the next request on keep-alive connections"`
+	ShutdownTimeout   time.Duration `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" default:"30s" usage:"maximum duration to drain in-flight requests on shutdown"`
+	ShutdownDelay     time.Duration `yaml:"shutdownDelay" env:"SHUTDOWN_DELAY" flag:"shutdown-delay" default:"0s" usage:"duration to keep serving with failing readiness before draining on shutdown"`
+}
+
+// Postgres configures the database connection.
+type Postgres struct {
+	Host     string `yaml:"host" env:"POSTGRES_HOST" flag:"postgres-host" default:"localhost" usage:"postgres host"`
+	Port     int    `yaml:"port" env:"POSTGRES_PORT" flag:"postgres-port" default:"5432" usage:"postgres port"`
+	DB       string `yaml:"db" env:"POSTGRES_DB" flag:"postgres-db" default:"example" usage:"postgres database name"`
+	User     string `yaml:"user" env:"POSTGRES_USER" flag:"postgres-user" default:"postgres" usage:"postgres user"`
+	Password string `yaml:"password" env:"POSTGRES_PASSWORD" flag:"postgres-password" secret:"true" usage:"postgres password"`
+	SSL      string `yaml:"ssl" env:"POSTGRES_SSL" flag:"postgres-ssl" default:"disable" usage:"postgres ssl mode [disable, allow, prefer, require, verify-ca, verify-full]"`
+
+	TxIsolation  string `yaml:"txIsolation" env:"POSTGRES_TX_ISOLATION" flag:"postgres-tx-isolation" default:"read committed" usage:"isolation level of transactions [read uncommitted, read committed, repeatable read, serializable]"`
+	TxMaxRetries int    `yaml:"txMaxRetries" env:"POSTGRES_TX_MAX_RETRIES" flag:"postgres-tx-max-retries" default:"3" usage:"retries of transactions failing on serialization failures or deadlocks"`
+}
+
+// ConnectionConfig returns the postgres connection configuration.
+func (p Postgres) ConnectionConfig() postgres.ConnectionConfig {
+	return postgres.ConnectionConfig{
+		User:     p.User,
+		Password: p.Password,
+		Host:     p.Host,
+		Port:     strconv.Itoa(p.Port),
+		DB:       p.DB,
+		SSL:      p.SSL,
+	}
+}
+
+// Auth configures how API requests are authenticated, requests are accepted
+// by any of the configured methods.
+type Auth struct {
+	BasicCredentials []string      `yaml:"basicCredentials" env:"AUTH_BASIC_CREDENTIALS" flag:"auth-basic-credentials" secret:"true" usage:"comma separated user:password pairs accepted through basic auth"`
+	BasicRoles       []string      `yaml:"basicRoles" env:"AUTH_BASIC_ROLES" flag:"auth-basic-roles" usage:"comma separated user=role [role...] assignments of basic auth users"`
+	Roles            []string      `yaml:"roles" env:"AUTH_ROLES" flag:"auth-roles" default:"reader=example:read,writer=example:read example:write,admin=example:read example:write example:admin log:admin" usage:"comma separated role=scope [scope...] definitions"`
+	APIKeys          bool          `yaml:"apiKeys" env:"AUTH_API_KEYS" flag:"auth-api-keys" default:"true" usage:"accept API keys stored in postgres"`
+	JWTHS256Secret   string        `yaml:"jwtHS256Secret" env:"AUTH_JWT_HS256_SECRET" flag:"auth-jwt-hs256-secret" secret:"true" usage:"shared secret of HS256 signed JWTs"`
+	JWTRS256KeyFile  string        `yaml:"jwtRS256KeyFile" env:"AUTH_JWT_RS256_KEY_FILE" flag:"auth-jwt-rs256-key-file" usage:"PEM file with the RSA public key of RS256 signed JWTs"`
+	JWTJWKSFile      string        `yaml:"jwtJWKSFile" env:"AUTH_JWT_JWKS_FILE" flag:"auth-jwt-jwks-file" usage:"JWKS file with the RSA public keys of RS256 signed JWTs"`
+	JWTIssuer        string        `yaml:"jwtIssuer" env:"AUTH_JWT_ISSUER" flag:"auth-jwt-issuer" usage:"required issuer of JWTs"`
+	JWTAudience      string        `yaml:"jwtAudience" env:"AUTH_JWT_AUDIENCE" flag:"auth-jwt-audience" usage:"required audience of JWTs"`
+	JWTMaxLifetime   time.Duration `yaml:"jwtMaxLifetime" env:"AUTH_JWT_MAX_LIFETIME" flag:"auth-jwt-max-lifetime" usage:"maximum time between the iat and exp claims of JWTs, which are then both required, 0 to only require exp"`
+}
+
+// JWT reports whether any JWT verification key is configured.
+func (a Auth) JWT() bool {
+	return a.JWTHS256Secret != "" || a.JWTRS256KeyFile != "" || a.JWTJWKSFile != ""
+}
+
+// Metrics configures the prometheus metrics.
+type Metrics struct {
+	Enabled bool `yaml:"enabled" env:"METRICS_ENABLED" flag:"metrics-enabled" default:"true" usage:"expose prometheus metrics on /metrics"`
+}
+
+// Outbox configures the relay publishing domain events from the outbox.
+type Outbox struct {
+	Publisher    string        `yaml:"publisher" env:"OUTBOX_PUBLISHER" flag:"outbox-publisher" default:"stdout" usage:"where events are published to [none, stdout, file, webhook], none leaves them to another relay"`
+	File         string        `yaml:"file" env:"OUTBOX_FILE" flag:"outbox-file" usage:"file events are appended to by the file publisher"`
+	WebhookURL   string        `yaml:"webhookURL" env:"OUTBOX_WEBHOOK_URL" flag:"outbox-webhook-url" usage:"URL events are posted to by the webhook publisher"`
+	PollInterval time.Duration `yaml:"pollInterval" env:"OUTBOX_POLL_INTERVAL" flag:"outbox-poll-interval" default:"5s" usage:"interval between polls for pending events, new events are also relayed on notification"`
+	BatchSize    int           `yaml:"batchSize" env:"OUTBOX_BATCH_SIZE" flag:"outbox-batch-size" default:"100" usage:"maximum number of events claimed per poll"`
+	MaxAttempts  int           `yaml:"maxAttempts" env:"OUTBOX_MAX_ATTEMPTS" flag:"outbox-max-attempts" default:"20" usage:"attempts to publish an event before it is dead"`
+	BaseBackoff  time.Duration `yaml:"baseBackoff" env:"OUTBOX_BASE_BACKOFF" flag:"outbox-base-backoff" default:"1s" usage:"delay after the first failed publish, doubled on every attempt"`
+	MaxBackoff   time.Duration `yaml:"maxBackoff" env:"OUTBOX_MAX_BACKOFF" flag:"outbox-max-backoff" default:"10m" usage:"maximum delay between attempts to publish an event"`
+	Lease        time.Duration `yaml:"lease" env:"OUTBOX_LEASE" flag:"outbox-lease" default:"5m" usage:"duration claimed events are hidden from other relays while published, should exceed the time to publish a batch"`
+}
+
+// Webhooks configures webhook subscriptions and their delivery.
+type Webhooks struct {
+	Enabled      bool          `yaml:"enabled" env:"WEBHOOKS_ENABLED" flag:"webhooks-enabled" default:"true" usage:"serve /api/webhooks and deliver events to subscriptions"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT" flag:"webhooks-timeout" default:"10s" usage:"timeout of a delivery request"`
+	MaxAttempts  int           `yaml:"maxAttempts" env:"WEBHOOKS_MAX_ATTEMPTS" flag:"webhooks-max-attempts" default:"10" usage:"attempts of a delivery before it is dead"`
+	BaseBackoff  time.Duration `yaml:"baseBackoff" env:"WEBHOOKS_BASE_BACKOFF" flag:"webhooks-base-backoff" default:"30s" usage:"delay after the first failed attempt, doubled on every attempt"`
+	MaxBackoff   time.Duration `yaml:"maxBackoff" env:"WEBHOOKS_MAX_BACKOFF" flag:"webhooks-max-backoff" default:"6h" usage:"maximum delay between attempts"`
+	PollInterval time.Duration `yaml:"pollInterval" env:"WEBHOOKS_POLL_INTERVAL" flag:"webhooks-poll-interval" default:"5s" usage:"interval between polls for due deliveries"`
+	BatchSize    int           `yaml:"batchSize" env:"WEBHOOKS_BATCH_SIZE" flag:"webhooks-batch-size" default:"20" usage:"maximum number of deliveries sent concurrently per poll"`
+	Lease        time.Duration `yaml:"lease" env:"WEBHOOKS_LEASE" flag:"webhooks-lease" default:"1m" usage:"duration claimed deliveries are hidden from other dispatchers while sent, must exceed the timeout"`
+}
+
+// Idempotency configures the replay of responses to requests with an
+// Idempotency-Key.
+type Idempotency struct {
+	Enabled       bool          `yaml:"enabled" env:"IDEMPOTENCY_ENABLED" flag:"idempotency-enabled" default:"true" usage:"honor the Idempotency-Key header of example creation"`
+	TTL           time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" flag:"idempotency-ttl" default:"24h" usage:"duration responses are replayed to retries with the same key"`
+	LockTimeout   time.Duration `yaml:"lockTimeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" flag:"idempotency-lock-timeout" default:"1m" usage:"duration a key stays reserved by its request in progress before a retry may take it over, should exceed the http write timeout"`
+	PurgeInterval time.Duration `yaml:"purgeInterval" env:"IDEMPOTENCY_PURGE_INTERVAL" flag:"idempotency-purge-interval" default:"1h" usage:"interval between deletions of expired keys"`
+}
+
+// RateLimit configures the rate limits of API clients.
+type RateLimit struct {
+	Enabled       bool          `yaml:"enabled" env:"RATE_LIMIT_ENABLED" flag:"rate-limit-enabled" default:"true" usage:"rate limit api requests per client"`
+	Store         string        `yaml:"store" env:"RATE_LIMIT_STORE" flag:"rate-limit-store" default:"memory" usage:"where buckets are stored [memory, postgres], postgres shares limits between replicas"`
+	Default       string        `yaml:"default" env:"RATE_LIMIT_DEFAULT" flag:"rate-limit-default" default:"300/1m" usage:"requests/window limit of routes without a route limit"`
+	Routes        []string      `yaml:"routes" env:"RATE_LIMIT_ROUTES" flag:"rate-limit-routes" default:"POST /api/example=60/1m,POST /api/webhooks=10/1m" usage:"comma separated METHOD pattern=requests/window route limits"`
+	IP            string        `yaml:"ip" env:"RATE_LIMIT_IP" flag:"rate-limit-ip" default:"1200/1m" usage:"requests/window limit of all api requests per IP address, applied before authentication so failing clients are limited too, empty disables it"`
+	PurgeInterval time.Duration `yaml:"purgeInterval" env:"RATE_LIMIT_PURGE_INTERVAL" flag:"rate-limit-purge-interval" default:"10m" usage:"interval between deletions of full buckets of the postgres store"`
+}
+
+// Rules returns the parsed rate limit rules.
+func (c RateLimit) Rules() (ratelimit.Rules, error) {
+	rules, err := ratelimit.ParseRules(c.Default, c.Routes)
+	if err != nil {
+		return ratelimit.Rules{}, err
+	}
+
+	if c.IP != "" {
+		if rules.IP, err = ratelimit.ParseLimit(c.IP); err != nil {
+			return ratelimit.Rules{}, fmt.Errorf("ip: %w", err)
+		}
+	}
+
+	return rules, nil
+}
+
+// Resilience configures the retries and circuit breaker of repository calls
+// failing temporarily.
+type Resilience struct {
+	Enabled          bool          `yaml:"enabled" env:"RESILIENCE_ENABLED" flag:"resilience-enabled" default:"true" usage:"retry and circuit break repository calls failing temporarily"`
+	RetryMaxAttempts int           `yaml:"retryMaxAttempts" env:"RESILIENCE_RETRY_MAX_ATTEMPTS" flag:"resilience-retry-max-attempts" default:"3" usage:"attempts of a call including the first, 1 disables retries"`
+	RetryBaseDelay   time.Duration `yaml:"retryBaseDelay" env:"RESILIENCE_RETRY_BASE_DELAY" flag:"resilience-retry-base-delay" default:"50ms" usage:"maximum delay before the first retry, doubled on every retry"`
+	RetryMaxDelay    time.Duration `yaml:"retryMaxDelay" env:"RESILIENCE_RETRY_MAX_DELAY" flag:"resilience-retry-max-delay" default:"1s" usage:"maximum delay between retries"`
+	BreakerThreshold int           `yaml:"breakerThreshold" env:"RESILIENCE_BREAKER_THRESHOLD" flag:"resilience-breaker-threshold" default:"5" usage:"consecutive failures opening the circuit breaker"`
+	BreakerTimeout   time.Duration `yaml:"breakerTimeout" env:"RESILIENCE_BREAKER_TIMEOUT" flag:"resilience-breaker-timeout" default:"30s" usage:"duration the circuit breaker stays open before a trial call"`
+}
+
+// Health configures the readiness checks.
+type Health struct {
+	Timeout         time.Duration `yaml:"timeout" env:"HEALTH_TIMEOUT" flag:"health-timeout" default:"2s" usage:"timeout of a single health check"`
+	CheckMigrations bool          `yaml:"checkMigrations" env:"HEALTH_CHECK_MIGRATIONS" flag:"health-check-migrations" default:"true" usage:"fail readiness unless the database is migrated to the latest embedded migration"`
+}
+
+// Tracing configures OpenTelemetry tracing.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" default:"none" usage:"where spans are exported to as JSON, not OTLP [none, stdout, json-file]"`
+	File        string  `yaml:"file" env:"TRACING_FILE" flag:"tracing-file" usage:"file spans are appended to by the json-file exporter"`
+	ServiceName string  `yaml:"serviceName" env:"TRACING_SERVICE_NAME" flag:"tracing-service-name" default:"example-service" usage:"service name reported with spans"`
+	SampleRatio float64 `yaml:"sampleRatio" env:"TRACING_SAMPLE_RATIO" flag:"tracing-sample-ratio" default:"1" usage:"ratio of new traces to sample in [0, 1]"`
+	// ShutdownTimeout bounds the flush of pending spans on shutdown, which
+	// happens after draining the http server.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" env:"TRACING_SHUTDOWN_TIMEOUT" flag:"tracing-shutdown-timeout" default:"5s" usage:"maximum duration to flush pending spans on shutdown"`
+}
+
+// Config returns the tracing configuration.
+func (t Tracing) Config() tracing.Config {
+	return tracing.Config{
+		Exporter:    tracing.Exporter(t.Exporter),
+		File:        t.File,
+		ServiceName: t.ServiceName,
+		SampleRatio: t.SampleRatio,
+	}
+}
+
+// Log configures the logger.
+type Log struct {
+	Level         logging.Level `yaml:"level" env:"LOG_LEVEL" flag:"log-level" default:"info" usage:"minimum logged level [debug, info, warn, error, dpanic, panic, fatal]"`
+	Levels        []string      `yaml:"levels" env:"LOG_LEVELS" flag:"log-levels" usage:"comma separated subsystem=level overrides of the level, e.g. http=debug; subsystems are http, example, outbox, webhook, idempotency and ratelimit"`
+	WithTimeStamp bool          `yaml:"withTimeStamp" env:"LOG_WITH_TIMESTAMP" flag:"log-with-timestamp" default:"true" usage:"log messages with timestamp"`
+	Caller        bool          `yaml:"caller" env:"LOG_CALLER" flag:"log-caller" default:"false" usage:"log the file and line of the caller"`
+	Stacktrace    string        `yaml:"stacktrace" env:"LOG_STACKTRACE" flag:"log-stacktrace" usage:"minimum level logged with a stack trace, none if empty [debug, info, warn, error, dpanic, panic, fatal]"`
+	Development   bool          `yaml:"development" env:"LOG_DEVELOPMENT" flag:"log-development" default:"false" usage:"panic on messages logged at dpanic level"`
+	Encoding      string        `yaml:"encoding" env:"LOG_ENCODING" flag:"log-encoding" default:"json" usage:"format of the logs written to stderr [json, console, logfmt]"`
+	Color         bool          `yaml:"color" env:"LOG_COLOR" flag:"log-color" default:"false" usage:"color the levels of the console encoding"`
+	File          LogFile       `yaml:"file"`
+	Sampling      LogSampling   `yaml:"sampling"`
+	RateLimits    []string      `yaml:"rateLimits" env:"LOG_RATE_LIMITS" flag:"log-rate-limits" usage:"comma separated key=limit/window limits of the entries of the messages starting with key, e.g. Response: 5=10/1s"`
+	// DroppedInterval is the interval between warnings of the entries
+	// dropped by sampling and rate limits.
+	DroppedInterval time.Duration `yaml:"droppedInterval" env:"LOG_DROPPED_INTERVAL" flag:"log-dropped-interval" default:"1m" usage:"interval between warnings of entries dropped by sampling and rate limits"`
+}
+
+// LogSampling configures the sampling of the entries of hot paths.
+type LogSampling struct {
+	Tick       time.Duration `yaml:"tick" env:"LOG_SAMPLING_TICK" flag:"log-sampling-tick" default:"1s" usage:"interval entries are counted in per level and message"`
+	First      int           `yaml:"first" env:"LOG_SAMPLING_FIRST" flag:"log-sampling-first" default:"100" usage:"entries logged per level and message each tick before sampling, 0 disables sampling"`
+	Thereafter int           `yaml:"thereafter" env:"LOG_SAMPLING_THEREAFTER" flag:"log-sampling-thereafter" default:"100" usage:"every n-th entry is logged past the first ones of a tick, 0 for none"`
+}
+
+// Config returns the sampling configuration.
+func (c LogSampling) Config() logging.Sampling {
+	return logging.Sampling{
+		Tick:       c.Tick,
+		First:      c.First,
+		Thereafter: c.Thereafter,
+	}
+}
+
+// LogRateLimits returns the parsed rate limits of log messages.
+func (c Log) LogRateLimits() ([]logging.RateLimit, error) {
+	return logging.ParseRateLimits(c.RateLimits)
+}
+
+// LogFile configures a rotated file logs are also written to.
+type LogFile struct {
+	Path        string        `yaml:"path" env:"LOG_FILE" flag:"log-file" usage:"file logs are also written to, none if empty"`
+	Level       string        `yaml:"level" env:"LOG_FILE_LEVEL" flag:"log-file-level" usage:"minimum level written to the file, all logged levels if empty [debug, info, warn, error, dpanic, panic, fatal]"`
+	Encoding    string        `yaml:"encoding" env:"LOG_FILE_ENCODING" flag:"log-file-encoding" default:"json" usage:"format of the logs written to the file [json, console, logfmt]"`
+	MaxSize     int           `yaml:"maxSize" env:"LOG_FILE_MAX_SIZE" flag:"log-file-max-size" default:"100" usage:"size in megabytes rotating the file, 0 for no limit"`
+	RotateEvery time.Duration `yaml:"rotateEvery" env:"LOG_FILE_ROTATE_EVERY" flag:"log-file-rotate-every" default:"24h" usage:"interval rotating the file, 0 for never"`
+	MaxAge      time.Duration `yaml:"maxAge" env:"LOG_FILE_MAX_AGE" flag:"log-file-max-age" default:"168h" usage:"age of rotated files to delete, 0 to keep them"`
+	MaxBackups  int           `yaml:"maxBackups" env:"LOG_FILE_MAX_BACKUPS" flag:"log-file-max-backups" default:"7" usage:"number of rotated files to keep, 0 for all"`
+}
+
+// Config returns the configuration of the file.
+func (c LogFile) Config() logging.FileConfig {
+	return logging.FileConfig{
+		Path:        c.Path,
+		MaxSize:     int64(c.MaxSize) << 20,
+		RotateEvery: c.RotateEvery,
+		MaxAge:      c.MaxAge,
+		MaxBackups:  c.MaxBackups,
+	}
+}
+
+// FileLevel returns the parsed level of the file, nil if all logged levels
+// are written to it.
+func (c Log) FileLevel() (*logging.Level, error) {
+	return parseOptionalLevel(c.File.Level)
+}
+
+// StacktraceLevel returns the parsed stacktrace level, nil if none.
+func (c Log) StacktraceLevel() (*logging.Level, error) {
+	return parseOptionalLevel(c.Stacktrace)
+}
+
+func parseOptionalLevel(text string) (*logging.Level, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	var l logging.Level
+	if err := l.UnmarshalText([]byte(text)); err != nil {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+// SubsystemLevels returns the parsed levels of subsystems.
+func (c Log) SubsystemLevels() (map[string]logging.Level, error) {
+	return logging.ParseSubsystemLevels(c.Levels)
+}
+
+// Migrations configures the database migrations.
+type Migrations struct {
+	Path    string        `yaml:"path" env:"MIGRATIONS_PATH" flag:"migrations-path" default:"migrations" usage:"path to the migration files of the migrate command"`
+	Auto    bool          `yaml:"auto" env:"AUTO_MIGRATE" flag:"auto-migrate" default:"false" usage:"apply the embedded migrations before serving, the postgres user must be allowed to change the schema"`
+	Timeout time.Duration `yaml:"timeout" env:"MIGRATIONS_TIMEOUT" flag:"migrations-timeout" default:"5m" usage:"timeout of the startup schema check, including waiting for and applying migrations"`
+}
+
+var (
+	sslModes         = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	txIsolations     = []string{"read uncommitted", "read committed", "repeatable read", "serializable"}
+	outboxPublishers = []string{"none", "stdout", "file", "webhook"}
+	tracingExporters = []string{string(tracing.ExporterNone), string(tracing.ExporterStdout), string(tracing.ExporterJSONFile)}
+	rateLimitStores  = []string{"memory", "postgres"}
+	logEncodings     = []string{string(logging.EncodingJSON), string(logging.EncodingConsole), string(logging.EncodingLogfmt)}
+)
+
+// Scope is the part of the configuration a command uses. Load only checks
+// the settings of the scope, so invalid settings of the other commands
+// sharing the environment don't stop a command from running.
+type Scope int
+
+const (
+	ScopeService  Scope = iota // Every setting, used by the service.
+	ScopeDatabase              // The postgres and migrations settings, used by the migrate command.
+)
+
+// includes reports whether the setting of key is in the scope.
+func (s Scope) includes(key string) bool {
+	if s == ScopeDatabase {
+		return strings.HasPrefix(key, "postgres.") || strings.HasPrefix(key, "migrations.")
+	}
+
+	return true
+}
+
+// Validate checks required fields and value ranges of every setting. All
+// problems are reported at once.
+func (c Config) Validate() error {
+	return c.validate(ScopeService)
+}
+
+func (c Config) validate(scope Scope) error {
+	errs := c.validateDatabase()
+	if scope == ScopeService {
+		errs = append(errs, c.validateService()...)
+	}
+
+	return errs.err()
+}
+
+// validateDatabase checks the postgres and migrations settings.
+func (c Config) validateDatabase() Errors {
+	var errs Errors
+
+	required := map[string]string{
+		"postgres.host": c.Postgres.Host,
+		"postgres.db":   c.Postgres.DB,
+		"postgres.user": c.Postgres.User,
+	}
+	for _, key := range sortedKeys(required) {
+		if strings.TrimSpace(required[key]) == "" {
+			errs = append(errs, fmt.Errorf("%s: is required", key))
+		}
+	}
+
+	if c.Postgres.Port < 1 || c.Postgres.Port > 65535 {
+		errs = append(errs, fmt.Errorf("postgres.port: %d is not in range [1, 65535]", c.Postgres.Port))
+	}
+
+	if !contains(sslModes, c.Postgres.SSL) {
+		errs = append(errs, fmt.Errorf("postgres.ssl: %q is not one of [%s]", c.Postgres.SSL, strings.Join(sslModes, ", ")))
+	}
+
+	if !contains(txIsolations, c.Postgres.TxIsolation) {
+		errs = append(errs, fmt.Errorf("postgres.txIsolation: %q is not one of [%s]", c.Postgres.TxIsolation, strings.Join(txIsolations, ", ")))
+	}
+
+	if c.Postgres.TxMaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("postgres.txMaxRetries: %d cannot be negative", c.Postgres.TxMaxRetries))
+	}
+
+	if c.Migrations.Path == "" {
+		errs = append(errs, fmt.Errorf("migrations.path: is required"))
+	}
+
+	if c.Migrations.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("migrations.timeout: %s must be positive", c.Migrations.Timeout))
+	}
+
+	return errs
+}
+
+// validateService checks the settings used by the service only.
+func (c Config) validateService() Errors {
+	var errs Errors
+
+	if strings.TrimSpace(c.HTTP.Address) == "" {
+		errs = append(errs, fmt.Errorf("http.address: is required"))
+	}
+
+	durations := map[string]time.Duration{
+		"auth.jwtMaxLifetime":    c.Auth.JWTMaxLifetime,
+		"http.readTimeout":       c.HTTP.ReadTimeout,
+		"http.readHeaderTimeout": c.HTTP.ReadHeaderTimeout,
+		"http.writeTimeout":      c.HTTP.WriteTimeout,
+		"http.idleTimeout":       c.HTTP.IdleTimeout,
+		"http.shutdownDelay":     c.HTTP.ShutdownDelay,
+		"log.file.rotateEvery":   c.Log.File.RotateEvery,
+		"log.file.maxAge":        c.Log.File.MaxAge,
+	}
+	for _, key := range sortedKeys(durations) {
+		if durations[key] < 0 {
+			errs = append(errs, fmt.Errorf("%s: %s cannot be negative", key, durations[key]))
+		}
+	}
+
+	if c.HTTP.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("http.shutdownTimeout: %s must be positive", c.HTTP.ShutdownTimeout))
+	}
+
+	if c.Health.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("health.timeout: %s must be positive", c.Health.Timeout))
+	}
+
+	if c.HTTP.AdminAddress != "" && c.HTTP.AdminAddress == c.HTTP.Address {
+		errs = append(errs, fmt.Errorf("http.adminAddress: must differ from http.address"))
+	}
+
+	if !contains(tracingExporters, c.Tracing.Exporter) {
+		errs = append(errs, fmt.Errorf("tracing.exporter: %q is not one of [%s]", c.Tracing.Exporter, strings.Join(tracingExporters, ", ")))
+	}
+
+	if c.Tracing.Exporter == string(tracing.ExporterJSONFile) && c.Tracing.File == "" {
+		errs = append(errs, fmt.Errorf("tracing.file: is required by the json-file exporter"))
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sampleRatio: %g is not in range [0, 1]", c.Tracing.SampleRatio))
+	}
+
+	if !contains(outboxPublishers, c.Outbox.Publisher) {
+		errs = append(errs, fmt.Errorf("outbox.publisher: %q is not one of [%s]", c.Outbox.Publisher, strings.Join(outboxPublishers, ", ")))
+	}
+
+	if c.Outbox.Publisher == "file" && c.Outbox.File == "" {
+		errs = append(errs, fmt.Errorf("outbox.file: is required by the file publisher"))
+	}
+
+	if c.Outbox.Publisher == "webhook" {
+		if u, err := url.Parse(c.Outbox.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("outbox.webhookURL: %q is not an absolute http(s) URL", c.Outbox.WebhookURL))
+		}
+	}
+
+	if c.Outbox.PollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("outbox.pollInterval: %s must be positive", c.Outbox.PollInterval))
+	}
+
+	if c.Outbox.BatchSize < 1 {
+		errs = append(errs, fmt.Errorf("outbox.batchSize: %d must be positive", c.Outbox.BatchSize))
+	}
+
+	if c.Outbox.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("outbox.maxAttempts: %d must be positive", c.Outbox.MaxAttempts))
+	}
+
+	positive := map[string]time.Duration{
+		"outbox.baseBackoff":        c.Outbox.BaseBackoff,
+		"outbox.maxBackoff":         c.Outbox.MaxBackoff,
+		"outbox.lease":              c.Outbox.Lease,
+		"webhooks.timeout":          c.Webhooks.Timeout,
+		"webhooks.baseBackoff":      c.Webhooks.BaseBackoff,
+		"webhooks.maxBackoff":       c.Webhooks.MaxBackoff,
+		"webhooks.pollInterval":     c.Webhooks.PollInterval,
+		"webhooks.lease":            c.Webhooks.Lease,
+		"idempotency.ttl":           c.Idempotency.TTL,
+		"idempotency.lockTimeout":   c.Idempotency.LockTimeout,
+		"idempotency.purgeInterval": c.Idempotency.PurgeInterval,
+		"rateLimit.purgeInterval":   c.RateLimit.PurgeInterval,
+		"resilience.retryBaseDelay": c.Resilience.RetryBaseDelay,
+		"resilience.retryMaxDelay":  c.Resilience.RetryMaxDelay,
+		"resilience.breakerTimeout": c.Resilience.BreakerTimeout,
+		"tracing.shutdownTimeout":   c.Tracing.ShutdownTimeout,
+		"log.sampling.tick":         c.Log.Sampling.Tick,
+		"log.droppedInterval":       c.Log.DroppedInterval,
+	}
+	for _, key := range sortedKeys(positive) {
+		if positive[key] <= 0 {
+			errs = append(errs, fmt.Errorf("%s: %s must be positive", key, positive[key]))
+		}
+	}
+
+	if c.Webhooks.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("webhooks.maxAttempts: %d must be positive", c.Webhooks.MaxAttempts))
+	}
+
+	if c.Webhooks.BatchSize < 1 {
+		errs = append(errs, fmt.Errorf("webhooks.batchSize: %d must be positive", c.Webhooks.BatchSize))
+	}
+
+	if c.Webhooks.Lease <= c.Webhooks.Timeout {
+		errs = append(errs, fmt.Errorf("webhooks.lease: %s must exceed webhooks.timeout %s", c.Webhooks.Lease, c.Webhooks.Timeout))
+	}
+
+	if c.Resilience.RetryMaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("resilience.retryMaxAttempts: %d must be positive", c.Resilience.RetryMaxAttempts))
+	}
+
+	if c.Resilience.BreakerThreshold < 1 {
+		errs = append(errs, fmt.Errorf("resilience.breakerThreshold: %d must be positive", c.Resilience.BreakerThreshold))
+	}
+
+	if !contains(rateLimitStores, c.RateLimit.Store) {
+		errs = append(errs, fmt.Errorf("rateLimit.store: %q is not one of [%s]", c.RateLimit.Store, strings.Join(rateLimitStores, ", ")))
+	}
+
+	if _, err := c.RateLimit.Rules(); err != nil {
+		errs = append(errs, fmt.Errorf("rateLimit: %w", err))
+	}
+
+	if _, err := c.Log.SubsystemLevels(); err != nil {
+		errs = append(errs, fmt.Errorf("log.levels: %w", err))
+	}
+
+	if _, err := c.Log.StacktraceLevel(); err != nil {
+		errs = append(errs, fmt.Errorf("log.stacktrace: %w", err))
+	}
+
+	if !contains(logEncodings, c.Log.Encoding) {
+		errs = append(errs, fmt.Errorf("log.encoding: %q is not one of [%s]", c.Log.Encoding, strings.Join(logEncodings, ", ")))
+	}
+
+	if !contains(logEncodings, c.Log.File.Encoding) {
+		errs = append(errs, fmt.Errorf("log.file.encoding: %q is not one of [%s]", c.Log.File.Encoding, strings.Join(logEncodings, ", ")))
+	}
+
+	if _, err := c.Log.FileLevel(); err != nil {
+		errs = append(errs, fmt.Errorf("log.file.level: %w", err))
+	}
+
+	if c.Log.File.MaxSize < 0 {
+		errs = append(errs, fmt.Errorf("log.file.maxSize: %d cannot be negative", c.Log.File.MaxSize))
+	}
+
+	if c.Log.File.MaxBackups < 0 {
+		errs = append(errs, fmt.Errorf("log.file.maxBackups: %d cannot be negative", c.Log.File.MaxBackups))
+	}
+
+	if c.Log.Sampling.First < 0 {
+		errs = append(errs, fmt.Errorf("log.sampling.first: %d cannot be negative", c.Log.Sampling.First))
+	}
+
+	if c.Log.Sampling.Thereafter < 0 {
+		errs = append(errs, fmt.Errorf("log.sampling.thereafter: %d cannot be negative", c.Log.Sampling.Thereafter))
+	}
+
+	if _, err := c.Log.LogRateLimits(); err != nil {
+		errs = append(errs, fmt.Errorf("log.rateLimits: %w", err))
+	}
+
+	if _, err := auth.ParseBasicCredentials(c.Auth.BasicCredentials); err != nil {
+		errs = append(errs, fmt.Errorf("auth.basicCredentials: %w", err))
+	}
+
+	if _, err := auth.ParseAssignments(c.Auth.BasicRoles); err != nil {
+		errs = append(errs, fmt.Errorf("auth.basicRoles: %w", err))
+	}
+
+	if _, err := auth.ParseAssignments(c.Auth.Roles); err != nil {
+		errs = append(errs, fmt.Errorf("auth.roles: %w", err))
+	}
+
+	if len(c.Auth.BasicCredentials) == 0 && !c.Auth.APIKeys && !c.Auth.JWT() {
+		errs = append(errs, fmt.Errorf("auth: at least one authentication method is required"))
+	}
+
+	return errs
+}
+
+// String returns the configuration with secret values redacted, making it
+// safe to log.
+func (c Config) String() string {
+	var b strings.Builder
+	for _, f := range fields(&c) {
+		value := fmt.Sprintf("%v", f.value.Interface())
+		if f.secret() && value != "" {
+			value = "***"
+		}
+
+		fmt.Fprintf(&b, "%s=%s\n", f.key, value)
+	}
+
+	return b.String()
+}
+
+// Errors is a list of configuration problems.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = "  - " + err.Error()
+	}
+
+	return fmt.Sprintf("invalid configuration:\n%s", strings.Join(msgs, "\n"))
+}
+
+// err returns nil for an empty list so callers can return it directly.
+func (e Errors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
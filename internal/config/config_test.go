@@ -0,0 +1,174 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	// Act.
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil, ScopeService)
+
+	// Assert.
+	require.NoError(t, err)
+	assert.Equal(t, ":80", cfg.HTTP.Address)
+	assert.Equal(t, 10*time.Second, cfg.HTTP.ReadTimeout)
+	assert.Equal(t, 5432, cfg.Postgres.Port)
+	assert.Equal(t, "disable", cfg.Postgres.SSL)
+	assert.Equal(t, logging.InfoLevel, cfg.Log.Level)
+	assert.True(t, cfg.Log.WithTimeStamp)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	// Arrange.
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+http:
+  address: ":1000"
+  readTimeout: 1s
+postgres:
+  host: file-host
+  port: 1000
+`), 0o600))
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("POSTGRES_HOST", "env-host")
+	t.Setenv("POSTGRES_PORT", "2000")
+
+	args := []string{"-postgres-port", "3000", "-log-level", "debug", "-log-with-timestamp=false"}
+
+	// Act.
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), args, ScopeService)
+
+	// Assert.
+	require.NoError(t, err)
+	assert.Equal(t, ":1000", cfg.HTTP.Address, "file overrides default")
+	assert.Equal(t, time.Second, cfg.HTTP.ReadTimeout, "file overrides default")
+	assert.Equal(t, "env-host", cfg.Postgres.Host, "env overrides file")
+	assert.Equal(t, 3000, cfg.Postgres.Port, "flag overrides env")
+	assert.Equal(t, logging.DebugLevel, cfg.Log.Level)
+	assert.False(t, cfg.Log.WithTimeStamp)
+}
+
+func TestLoadJSONFile(t *testing.T) {
+	// Arrange.
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"postgres": {"db": "json-db"}}`), 0o600))
+
+	// Act.
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", path}, ScopeService)
+
+	// Assert.
+	require.NoError(t, err)
+	assert.Equal(t, "json-db", cfg.Postgres.DB)
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	// Arrange.
+	t.Setenv("POSTGRES_PORT", "not-a-port")
+	t.Setenv("HTTP_READ_TIMEOUT", "forever")
+
+	args := []string{"-log-level", "loud"}
+
+	// Act.
+	_, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), args, ScopeService)
+
+	// Assert.
+	var errs Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 3)
+	assert.Contains(t, err.Error(), "postgres.port")
+	assert.Contains(t, err.Error(), "http.readTimeout")
+	assert.Contains(t, err.Error(), "log.level")
+}
+
+func TestLoadDatabaseScope(t *testing.T) {
+	// Arrange.
+	t.Setenv("WEBHOOKS_TIMEOUT", "forever")
+	t.Setenv("AUTH_JWT_MAX_LIFETIME", "-1h")
+	t.Setenv("RATE_LIMIT_IP", "unlimited")
+	t.Setenv("POSTGRES_DB", "migrated")
+
+	// Act.
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil, ScopeDatabase)
+	_, serviceErr := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil, ScopeService)
+
+	// Assert.
+	require.NoError(t, err, "settings of the service should not be checked")
+	assert.Equal(t, "migrated", cfg.Postgres.DB)
+
+	var errs Errors
+	require.ErrorAs(t, serviceErr, &errs)
+	assert.Len(t, errs, 1, "parse errors are reported before validation")
+	assert.Contains(t, serviceErr.Error(), "webhooks.timeout")
+}
+
+func TestLoadDatabaseScopeReportsDatabaseErrors(t *testing.T) {
+	// Arrange.
+	t.Setenv("WEBHOOKS_TIMEOUT", "forever")
+	t.Setenv("WEBHOOKS_BATCH_SIZE", "0")
+	t.Setenv("MIGRATIONS_TIMEOUT", "0s")
+
+	args := []string{"-postgres-ssl", "maybe"}
+
+	// Act.
+	_, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), args, ScopeDatabase)
+
+	// Assert.
+	var errs Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 2)
+	assert.Contains(t, err.Error(), "postgres.ssl")
+	assert.Contains(t, err.Error(), "migrations.timeout")
+}
+
+func TestValidate(t *testing.T) {
+	// Arrange.
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil, ScopeService)
+	require.NoError(t, err)
+
+	cfg.Postgres.Host = ""
+	cfg.Postgres.Port = 70000
+	cfg.Postgres.SSL = "maybe"
+	cfg.HTTP.WriteTimeout = -time.Second
+	cfg.Log.Stacktrace = "loud"
+	cfg.Log.Encoding = "xml"
+	cfg.Log.File.MaxAge = -time.Hour
+	cfg.Log.RateLimits = []string{"unlimited"}
+
+	// Act.
+	err = cfg.Validate()
+
+	// Assert.
+	var errs Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 8)
+	assert.Contains(t, err.Error(), "log.stacktrace")
+	assert.Contains(t, err.Error(), "log.encoding")
+	assert.Contains(t, err.Error(), "log.file.maxAge")
+	assert.Contains(t, err.Error(), "log.rateLimits")
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+	// Arrange.
+	t.Setenv("POSTGRES_PASSWORD", "hunter2")
+
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil, ScopeService)
+	require.NoError(t, err)
+
+	// Act.
+	s := cfg.String()
+
+	// Assert.
+	assert.NotContains(t, s, "hunter2")
+	assert.Contains(t, s, "postgres.password=***")
+	assert.Contains(t, s, "postgres.host=localhost")
+}
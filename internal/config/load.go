@@ -0,0 +1,305 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFileFlag = "config"
+	configFileEnv  = "CONFIG_FILE"
+)
+
+// Load loads the configuration. Settings are applied in increasing order of
+// precedence: defaults, the configuration file, environment variables and
+// finally command-line flags.
+//
+// A flag for every setting, plus -config for the configuration file (also
+// settable through CONFIG_FILE), is registered on fs before args are parsed,
+// so callers may register their own flags on fs beforehand and read fs.Args()
+// afterwards.
+//
+// Every invalid or missing setting of scope is reported at once through
+// Errors. Environment variables and flags of settings outside of scope are
+// applied if valid and otherwise ignored.
+func Load(fs *flag.FlagSet, args []string, scope Scope) (Config, error) {
+	var (
+		cfg    Config
+		fields = fields(&cfg)
+		flags  = map[string]*flagValue{}
+	)
+
+	// Flags are parsed first since they may point out the configuration
+	// file, their values are recorded and only applied last.
+	for _, f := range fields {
+		name := f.tag.Get("flag")
+		if name == "" {
+			continue
+		}
+
+		fv := &flagValue{def: f.tag.Get("default"), isBool: f.value.Kind() == reflect.Bool}
+		fs.Var(fv, name, f.tag.Get("usage"))
+		flags[f.key] = fv
+	}
+
+	configFile := fs.String(configFileFlag, "", "path to a YAML or JSON configuration file (env "+configFileEnv+")")
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	var errs Errors
+
+	for _, f := range fields {
+		def, ok := f.tag.Lookup("default")
+		if !ok {
+			continue
+		}
+
+		if err := setValue(f.value, def); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid default %q: %w", f.key, def, err))
+		}
+	}
+
+	if *configFile == "" {
+		*configFile = os.Getenv(configFileEnv)
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, f := range fields {
+		env := f.tag.Get("env")
+		if env == "" {
+			continue
+		}
+
+		raw, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+
+		if err := setValue(f.value, raw); err != nil && scope.includes(f.key) {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q from env %s: %w", f.key, f.redact(raw), env, err))
+		}
+	}
+
+	for _, f := range fields {
+		fv, ok := flags[f.key]
+		if !ok || !fv.set {
+			continue
+		}
+
+		if err := setValue(f.value, fv.raw); err != nil && scope.includes(f.key) {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q from flag -%s: %w", f.key, f.redact(fv.raw), f.tag.Get("flag"), err))
+		}
+	}
+
+	// Parse errors would only add noise to validation, e.g. an unparsable
+	// port would also be reported as out of range.
+	if len(errs) > 0 {
+		return Config{}, errs
+	}
+
+	if err := cfg.validate(scope); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile decodes a YAML file into cfg, as YAML is a superset of JSON both
+// formats are supported. Unknown keys are reported to catch typos.
+func loadFile(path string, cfg *Config) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("could not decode config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// field is a single setting of the configuration.
+type field struct {
+	key   string // Dot separated path, e.g. postgres.port.
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+func (f field) secret() bool {
+	return f.tag.Get("secret") == "true"
+}
+
+// redact hides raw if the field is secret.
+func (f field) redact(raw string) string {
+	if f.secret() {
+		return "***"
+	}
+
+	return raw
+}
+
+// fields returns all settings of cfg, the returned values are addressable
+// and point into cfg.
+func fields(cfg *Config) []field {
+	return collectFields(reflect.ValueOf(cfg).Elem(), "")
+}
+
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+
+		key := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if key == "" {
+			key = sf.Name
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !isTextUnmarshaler(fv) {
+			fields = append(fields, collectFields(fv, key)...)
+			continue
+		}
+
+		fields = append(fields, field{key: key, value: fv, tag: sf.Tag})
+	}
+
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue parses raw into v according to its type.
+func setValue(v reflect.Value, raw string) error {
+	if isTextUnmarshaler(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+
+		// Comma separated list.
+		values := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = reflect.Append(values, reflect.ValueOf(s).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(values)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func isTextUnmarshaler(v reflect.Value) bool {
+	if !v.CanAddr() {
+		return false
+	}
+
+	_, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+// flagValue records the raw value of a flag so it can be applied after the
+// other sources.
+type flagValue struct {
+	def    string
+	raw    string
+	set    bool
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.def
+}
+
+// IsBoolFlag allows boolean flags to be set without a value, e.g. -flag.
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+func (f *flagValue) Set(s string) error {
+	f.raw = s
+	f.set = true
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
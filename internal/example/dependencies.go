@@ -10,4 +10,28 @@ import (
 type exampleRepository interface {
 	FindOneByID(ctx context.Context, id uuid.UUID) (Example, error)
 	Save(ctx context.Context, ex Example) error
+	List(ctx context.Context, q ListQuery) ([]Example, error)
+	// Update stores ex if the stored version equals expectedVersion, a nil
+	// expectedVersion updates unconditionally. The updated example is
+	// returned.
+	Update(ctx context.Context, ex Example, expectedVersion *int) (Example, error)
+	// Delete removes the example if the stored version equals
+	// expectedVersion, a nil expectedVersion deletes unconditionally.
+	Delete(ctx context.Context, id uuid.UUID, expectedVersion *int) error
+}
+
+//go:generate moq -out mock_tx_manager_test.go . txManager
+type txManager interface {
+	// WithTx runs fn in a transaction committed when fn returns nil.
+	// Repository calls made with the ctx passed to fn join the transaction.
+	// fn may be retried, so it must not have side effects outside of the
+	// repositories.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+//go:generate moq -out mock_event_store_test.go . eventStore
+type eventStore interface {
+	// Append stores events to be published, in the transaction of ctx if
+	// any so they are only published if the change is committed.
+	Append(ctx context.Context, events ...Event) error
 }
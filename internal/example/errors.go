@@ -1,6 +1,9 @@
 package example
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+)
 
 // Inspiration from:
 // https://www.joeshaw.org/error-handling-in-go-http-applications/
@@ -26,25 +29,92 @@ func (e sentinelAPIError) APIError() (int, string) {
 
 var (
 	ErrAuth       = &sentinelAPIError{status: http.StatusUnauthorized, msg: "invalid token"}
+	ErrForbidden  = &sentinelAPIError{status: http.StatusForbidden, msg: "forbidden"}
 	ErrValidation = &sentinelAPIError{status: http.StatusBadRequest, msg: "invalid request"}
 	ErrNotFound   = &sentinelAPIError{status: http.StatusNotFound, msg: "not found"}
 	ErrTemporary  = &sentinelAPIError{status: http.StatusServiceUnavailable, msg: "temporary error"}
+	ErrTimeout    = &sentinelAPIError{status: http.StatusGatewayTimeout, msg: "timeout"}
+	ErrConflict   = &sentinelAPIError{status: http.StatusConflict, msg: "conflict"}
+	ErrRateLimit  = &sentinelAPIError{status: http.StatusTooManyRequests, msg: "too many requests"}
+
+	ErrPreconditionFailed  = &sentinelAPIError{status: http.StatusPreconditionFailed, msg: "precondition failed"}
+	ErrIdempotencyKeyReuse = &sentinelAPIError{status: http.StatusUnprocessableEntity, msg: "idempotency key reused with a different request"}
 )
 
 // sentinelWrappedError....
 type sentinelWrappedError struct {
 	error
 	sentinel *sentinelAPIError
+	detail   string // Replaces the message of sentinel if not empty.
 }
 
 func (e sentinelWrappedError) Is(err error) bool {
 	return e.sentinel == err
 }
 
+func (e sentinelWrappedError) Unwrap() error {
+	return e.error
+}
+
 func (e sentinelWrappedError) APIError() (int, string) {
-	return e.sentinel.APIError()
+	status, msg := e.sentinel.APIError()
+	if e.detail != "" {
+		msg = e.detail
+	}
+
+	return status, msg
 }
 
 func WrapError(err error, sentinel *sentinelAPIError) error {
 	return sentinelWrappedError{error: err, sentinel: sentinel}
 }
+
+// WrapErrorDetail wraps err like WrapError, responding with the API-safe
+// detail instead of the message of sentinel.
+func WrapErrorDetail(err error, sentinel *sentinelAPIError, detail string) error {
+	return sentinelWrappedError{error: err, sentinel: sentinel, detail: detail}
+}
+
+// alertError marks an error operators must be alerted of, as it won't go
+// away by itself, e.g. a missing database privilege.
+type alertError struct {
+	error
+}
+
+func (e alertError) Unwrap() error {
+	return e.error
+}
+
+// Alert marks err to be alerted of when it is logged.
+func Alert(err error) error {
+	return alertError{err}
+}
+
+// IsAlert reports whether err was marked by Alert.
+func IsAlert(err error) bool {
+	var a alertError
+	return errors.As(err, &a)
+}
+
+// unavailableError marks an error caused by a dependency being unavailable,
+// e.g. a refused connection, as opposed to contention with other requests
+// such as a deadlock, which is temporary too but says nothing of the health
+// of the dependency.
+type unavailableError struct {
+	error
+}
+
+func (e unavailableError) Unwrap() error {
+	return e.error
+}
+
+// Unavailable marks err as caused by an unavailable dependency.
+func Unavailable(err error) error {
+	return unavailableError{err}
+}
+
+// IsUnavailable reports whether err was marked by Unavailable.
+func IsUnavailable(err error) bool {
+	var u unavailableError
+	return errors.As(err, &u)
+}
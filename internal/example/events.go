@@ -0,0 +1,55 @@
+package example
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType is the kind of change an Event describes.
+type EventType string
+
+const (
+	EventExampleCreated EventType = "example.created"
+	EventExampleUpdated EventType = "example.updated"
+	EventExampleDeleted EventType = "example.deleted"
+)
+
+// Event is a domain event about a change of an example. Events are stored
+// in the same transaction as the change and delivered at least once, so
+// consumers should dedupe them by ID.
+type Event struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       EventType       `json:"type"`
+	ExampleID  uuid.UUID       `json:"example_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"` // The example after the change, only its id when deleted.
+	// Owner of the example, events are only delivered to the webhooks of
+	// their owner and of admins.
+	Owner string `json:"owner,omitempty"`
+}
+
+func newEvent(t EventType, ex Example) (Event, error) {
+	var data any = ex
+	if t == EventExampleDeleted {
+		data = struct {
+			ID uuid.UUID `json:"id"`
+		}{ID: ex.ID}
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("could not encode %s event: %w", t, err)
+	}
+
+	return Event{
+		ID:         uuid.New(),
+		Type:       t,
+		ExampleID:  ex.ID,
+		OccurredAt: time.Now().UTC(),
+		Data:       b,
+		Owner:      ex.Owner,
+	}, nil
+}
@@ -1,20 +1,26 @@
 package example
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Example struct {
-	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"` // Incremented on every update, used for optimistic concurrency.
+	Owner     string    `json:"owner"`   // ID of the principal that created the example.
 }
 
-func newExample(dto ExampleDTO) Example {
+func newExample(dto ExampleDTO, owner string) Example {
 	return Example{
-		ID:   uuid.New(),
-		Name: dto.Name,
+		ID:        uuid.New(),
+		Name:      dto.Name,
+		CreatedAt: time.Now().UTC(),
+		Version:   1,
+		Owner:     owner,
 	}
 }
 
@@ -22,10 +28,31 @@ type ExampleDTO struct {
 	Name string `json:"name"`
 }
 
+// Validate returns a *ValidationError with every invalid field.
 func (dto ExampleDTO) Validate() error {
+	var v ValidationError
+
 	if dto.Name == "" {
-		return fmt.Errorf("name cannot be empty")
+		v.Add("name", "cannot be empty")
+	}
+
+	return v.Err()
+}
+
+// ExamplePatchDTO contains the fields to change, nil fields are left as is.
+type ExamplePatchDTO struct {
+	Name *string `json:"name"`
+}
+
+// apply returns a DTO with the patch applied to ex.
+func (p ExamplePatchDTO) apply(ex Example) ExampleDTO {
+	dto := ExampleDTO{
+		Name: ex.Name,
+	}
+
+	if p.Name != nil {
+		dto.Name = *p.Name
 	}
 
-	return nil
+	return dto
 }
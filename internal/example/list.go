@@ -0,0 +1,129 @@
+package example
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// SortOrder is the order examples are listed in by creation time.
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// NameMatch defines how the name filter is matched.
+type NameMatch string
+
+const (
+	NameMatchPrefix   NameMatch = "prefix"
+	NameMatchContains NameMatch = "contains"
+)
+
+// ListParams are the parameters for listing examples. Zero values are
+// replaced by defaults.
+type ListParams struct {
+	Name      string // Name filter, empty means no filtering.
+	NameMatch NameMatch
+	Order     SortOrder
+	Limit     int
+	Cursor    string // Opaque cursor from a previous Page.
+}
+
+func (p ListParams) withDefaults() ListParams {
+	if p.NameMatch == "" {
+		p.NameMatch = NameMatchPrefix
+	}
+
+	if p.Order == "" {
+		p.Order = SortAsc
+	}
+
+	if p.Limit == 0 {
+		p.Limit = DefaultListLimit
+	}
+
+	return p
+}
+
+// Validate returns a *ValidationError with every invalid parameter, named
+// by their query parameters.
+func (p ListParams) Validate() error {
+	var v ValidationError
+
+	if p.NameMatch != NameMatchPrefix && p.NameMatch != NameMatchContains {
+		v.Add("name_match", "must be one of [%s, %s]", NameMatchPrefix, NameMatchContains)
+	}
+
+	if p.Order != SortAsc && p.Order != SortDesc {
+		v.Add("sort", "must be one of [%s, %s]", SortAsc, SortDesc)
+	}
+
+	if p.Limit < 1 || p.Limit > MaxListLimit {
+		v.Add("limit", "must be between 1 and %d", MaxListLimit)
+	}
+
+	return v.Err()
+}
+
+// ListQuery is a keyset paginated query for examples.
+type ListQuery struct {
+	Name      string
+	NameMatch NameMatch
+	Order     SortOrder
+	Limit     int
+	After     *Cursor // Only list examples after the cursor, nil for the first page.
+}
+
+// Page is a page of listed examples.
+type Page struct {
+	Examples   []Example `json:"data"`
+	NextCursor string    `json:"next_cursor,omitempty"` // Empty on the last page.
+}
+
+// Cursor points at the last example of a page. Examples are ordered by
+// (created_at, id) so the cursor holds both to be unique.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+	Order     SortOrder `json:"o"`
+}
+
+func newCursor(ex Example, order SortOrder) Cursor {
+	return Cursor{
+		CreatedAt: ex.CreatedAt,
+		ID:        ex.ID,
+		Order:     order,
+	}
+}
+
+// Encode returns the cursor as an opaque URL safe string.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor decodes a cursor created by Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	return c, nil
+}
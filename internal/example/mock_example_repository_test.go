@@ -19,12 +19,21 @@ var _ exampleRepository = &exampleRepositoryMock{}
 //
 //		// make and configure a mocked exampleRepository
 //		mockedexampleRepository := &exampleRepositoryMock{
+//			DeleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersion *int) error {
+//				panic("mock out the Delete method")
+//			},
 //			FindOneByIDFunc: func(ctx context.Context, id uuid.UUID) (Example, error) {
 //				panic("mock out the FindOneByID method")
 //			},
+//			ListFunc: func(ctx context.Context, q ListQuery) ([]Example, error) {
+//				panic("mock out the List method")
+//			},
 //			SaveFunc: func(ctx context.Context, ex Example) error {
 //				panic("mock out the Save method")
 //			},
+//			UpdateFunc: func(ctx context.Context, ex Example, expectedVersion *int) (Example, error) {
+//				panic("mock out the Update method")
+//			},
 //		}
 //
 //		// use mockedexampleRepository in code that requires exampleRepository
@@ -32,14 +41,32 @@ var _ exampleRepository = &exampleRepositoryMock{}
 //
 //	}
 type exampleRepositoryMock struct {
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(ctx context.Context, id uuid.UUID, expectedVersion *int) error
+
 	// FindOneByIDFunc mocks the FindOneByID method.
 	FindOneByIDFunc func(ctx context.Context, id uuid.UUID) (Example, error)
 
+	// ListFunc mocks the List method.
+	ListFunc func(ctx context.Context, q ListQuery) ([]Example, error)
+
 	// SaveFunc mocks the Save method.
 	SaveFunc func(ctx context.Context, ex Example) error
 
+	// UpdateFunc mocks the Update method.
+	UpdateFunc func(ctx context.Context, ex Example, expectedVersion *int) (Example, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// Delete holds details about calls to the Delete method.
+		Delete []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID uuid.UUID
+			// ExpectedVersion is the expectedVersion argument value.
+			ExpectedVersion *int
+		}
 		// FindOneByID holds details about calls to the FindOneByID method.
 		FindOneByID []struct {
 			// Ctx is the ctx argument value.
@@ -47,6 +74,13 @@ type exampleRepositoryMock struct {
 			// ID is the id argument value.
 			ID uuid.UUID
 		}
+		// List holds details about calls to the List method.
+		List []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Q is the q argument value.
+			Q ListQuery
+		}
 		// Save holds details about calls to the Save method.
 		Save []struct {
 			// Ctx is the ctx argument value.
@@ -54,9 +88,61 @@ type exampleRepositoryMock struct {
 			// Ex is the ex argument value.
 			Ex Example
 		}
+		// Update holds details about calls to the Update method.
+		Update []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Ex is the ex argument value.
+			Ex Example
+			// ExpectedVersion is the expectedVersion argument value.
+			ExpectedVersion *int
+		}
 	}
+	lockDelete      sync.RWMutex
 	lockFindOneByID sync.RWMutex
+	lockList        sync.RWMutex
 	lockSave        sync.RWMutex
+	lockUpdate      sync.RWMutex
+}
+
+// Delete calls DeleteFunc.
+func (mock *exampleRepositoryMock) Delete(ctx context.Context, id uuid.UUID, expectedVersion *int) error {
+	if mock.DeleteFunc == nil {
+		panic("exampleRepositoryMock.DeleteFunc: method is nil but exampleRepository.Delete was just called")
+	}
+	callInfo := struct {
+		Ctx             context.Context
+		ID              uuid.UUID
+		ExpectedVersion *int
+	}{
+		Ctx:             ctx,
+		ID:              id,
+		ExpectedVersion: expectedVersion,
+	}
+	mock.lockDelete.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, callInfo)
+	mock.lockDelete.Unlock()
+	return mock.DeleteFunc(ctx, id, expectedVersion)
+}
+
+// DeleteCalls gets all the calls that were made to Delete.
+// Check the length with:
+//
+//	len(mockedexampleRepository.DeleteCalls())
+func (mock *exampleRepositoryMock) DeleteCalls() []struct {
+	Ctx             context.Context
+	ID              uuid.UUID
+	ExpectedVersion *int
+} {
+	var calls []struct {
+		Ctx             context.Context
+		ID              uuid.UUID
+		ExpectedVersion *int
+	}
+	mock.lockDelete.RLock()
+	calls = mock.calls.Delete
+	mock.lockDelete.RUnlock()
+	return calls
 }
 
 // FindOneByID calls FindOneByIDFunc.
@@ -95,6 +181,42 @@ func (mock *exampleRepositoryMock) FindOneByIDCalls() []struct {
 	return calls
 }
 
+// List calls ListFunc.
+func (mock *exampleRepositoryMock) List(ctx context.Context, q ListQuery) ([]Example, error) {
+	if mock.ListFunc == nil {
+		panic("exampleRepositoryMock.ListFunc: method is nil but exampleRepository.List was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Q   ListQuery
+	}{
+		Ctx: ctx,
+		Q:   q,
+	}
+	mock.lockList.Lock()
+	mock.calls.List = append(mock.calls.List, callInfo)
+	mock.lockList.Unlock()
+	return mock.ListFunc(ctx, q)
+}
+
+// ListCalls gets all the calls that were made to List.
+// Check the length with:
+//
+//	len(mockedexampleRepository.ListCalls())
+func (mock *exampleRepositoryMock) ListCalls() []struct {
+	Ctx context.Context
+	Q   ListQuery
+} {
+	var calls []struct {
+		Ctx context.Context
+		Q   ListQuery
+	}
+	mock.lockList.RLock()
+	calls = mock.calls.List
+	mock.lockList.RUnlock()
+	return calls
+}
+
 // Save calls SaveFunc.
 func (mock *exampleRepositoryMock) Save(ctx context.Context, ex Example) error {
 	if mock.SaveFunc == nil {
@@ -130,3 +252,43 @@ func (mock *exampleRepositoryMock) SaveCalls() []struct {
 	mock.lockSave.RUnlock()
 	return calls
 }
+
+// Update calls UpdateFunc.
+func (mock *exampleRepositoryMock) Update(ctx context.Context, ex Example, expectedVersion *int) (Example, error) {
+	if mock.UpdateFunc == nil {
+		panic("exampleRepositoryMock.UpdateFunc: method is nil but exampleRepository.Update was just called")
+	}
+	callInfo := struct {
+		Ctx             context.Context
+		Ex              Example
+		ExpectedVersion *int
+	}{
+		Ctx:             ctx,
+		Ex:              ex,
+		ExpectedVersion: expectedVersion,
+	}
+	mock.lockUpdate.Lock()
+	mock.calls.Update = append(mock.calls.Update, callInfo)
+	mock.lockUpdate.Unlock()
+	return mock.UpdateFunc(ctx, ex, expectedVersion)
+}
+
+// UpdateCalls gets all the calls that were made to Update.
+// Check the length with:
+//
+//	len(mockedexampleRepository.UpdateCalls())
+func (mock *exampleRepositoryMock) UpdateCalls() []struct {
+	Ctx             context.Context
+	Ex              Example
+	ExpectedVersion *int
+} {
+	var calls []struct {
+		Ctx             context.Context
+		Ex              Example
+		ExpectedVersion *int
+	}
+	mock.lockUpdate.RLock()
+	calls = mock.calls.Update
+	mock.lockUpdate.RUnlock()
+	return calls
+}
@@ -0,0 +1,9 @@
+package example
+
+// Scopes granting access to examples.
+const (
+	ScopeRead  = "example:read"
+	ScopeWrite = "example:write"
+	// ScopeAdmin allows changing examples owned by others.
+	ScopeAdmin = "example:admin"
+)
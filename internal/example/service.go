@@ -4,34 +4,61 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/bratteby/go-service-template/internal/auth"
 	"github.com/bratteby/go-service-template/internal/logging"
 	"github.com/google/uuid"
 )
 
 type Service struct {
 	ExampleRepository exampleRepository
-	Logger            *logging.Logger
-	// logger etc.
+	// TxManager makes read-modify-write operations atomic, without it each
+	// repository call stands alone.
+	TxManager txManager
+	// Events records the domain events of changes, nil disables them.
+	Events eventStore
+	// Logger logs changes of examples, the logger of the request context if
+	// nil.
+	Logger *logging.Logger
 }
 
-func (s Service) CreateExample(ctx context.Context, dto ExampleDTO) (Example, error) {
+func (s Service) CreateExample(ctx context.Context, dto ExampleDTO) (_ Example, err error) {
+	ctx, span := startSpan(ctx, "CreateExample")
+	defer endSpan(span, &err)
+
 	// Validate
 	if err := dto.Validate(); err != nil {
-		return Example{}, WrapError(err, ErrValidation)
+		return Example{}, err
 	}
 
 	// Create
-	ex := newExample(dto)
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return Example{}, WrapError(fmt.Errorf("no principal to own example"), ErrForbidden)
+	}
+
+	ex := newExample(dto, p.ID())
 
 	// Store
-	if err := s.ExampleRepository.Save(ctx, ex); err != nil {
-		return Example{}, fmt.Errorf("could not store example %w", err)
+	err = s.withTx(ctx, func(ctx context.Context) error {
+		if err := s.ExampleRepository.Save(ctx, ex); err != nil {
+			return fmt.Errorf("could not store example %w", err)
+		}
+
+		return s.recordEvent(ctx, EventExampleCreated, ex)
+	})
+	if err != nil {
+		return Example{}, err
 	}
 
+	s.logChange(ctx, EventExampleCreated, ex.ID)
+
 	return ex, nil
 }
 
-func (s Service) GetExampleByID(ctx context.Context, id uuid.UUID) (Example, error) {
+func (s Service) GetExampleByID(ctx context.Context, id uuid.UUID) (_ Example, err error) {
+	ctx, span := startSpan(ctx, "GetExampleByID", exampleIDAttribute(id.String()))
+	defer endSpan(span, &err)
+
 	ex, err := s.ExampleRepository.FindOneByID(ctx, id)
 	if err != nil {
 		return Example{}, fmt.Errorf("could not get example by id: %s, %w", id, err)
@@ -39,3 +66,228 @@ func (s Service) GetExampleByID(ctx context.Context, id uuid.UUID) (Example, err
 
 	return ex, nil
 }
+
+// ListExamples lists a page of examples, the next page is fetched by passing
+// the returned NextCursor in the params.
+func (s Service) ListExamples(ctx context.Context, params ListParams) (_ Page, err error) {
+	ctx, span := startSpan(ctx, "ListExamples")
+	defer endSpan(span, &err)
+
+	params = params.withDefaults()
+
+	if err := params.Validate(); err != nil {
+		return Page{}, err
+	}
+
+	q := ListQuery{
+		Name:      params.Name,
+		NameMatch: params.NameMatch,
+		Order:     params.Order,
+		// Fetch one extra to know if there is a next page.
+		Limit: params.Limit + 1,
+	}
+
+	if params.Cursor != "" {
+		cursor, err := DecodeCursor(params.Cursor)
+		if err != nil {
+			return Page{}, InvalidField("cursor", "is malformed")
+		}
+
+		if cursor.Order != params.Order {
+			return Page{}, InvalidField("cursor", "does not match sort order")
+		}
+
+		q.After = &cursor
+	}
+
+	examples, err := s.ExampleRepository.List(ctx, q)
+	if err != nil {
+		return Page{}, fmt.Errorf("could not list examples: %w", err)
+	}
+
+	page := Page{
+		Examples: examples,
+	}
+
+	if len(examples) > params.Limit {
+		page.Examples = examples[:params.Limit]
+		page.NextCursor = newCursor(page.Examples[params.Limit-1], params.Order).Encode()
+	}
+
+	return page, nil
+}
+
+// UpdateExample replaces the example with the given id. The update is only
+// performed if version matches the stored version, otherwise ErrConflict is
+// returned. A nil version updates unconditionally.
+func (s Service) UpdateExample(ctx context.Context, id uuid.UUID, dto ExampleDTO, version *int) (_ Example, err error) {
+	ctx, span := startSpan(ctx, "UpdateExample", exampleIDAttribute(id.String()))
+	defer endSpan(span, &err)
+
+	var ex Example
+	err = s.withTx(ctx, func(ctx context.Context) error {
+		current, err := s.ExampleRepository.FindOneByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("could not get example by id: %s, %w", id, err)
+		}
+
+		ex, err = s.update(ctx, current, dto, version)
+		if err != nil {
+			return err
+		}
+
+		return s.recordEvent(ctx, EventExampleUpdated, ex)
+	})
+	if err != nil {
+		return Example{}, err
+	}
+
+	s.logChange(ctx, EventExampleUpdated, ex.ID)
+
+	return ex, nil
+}
+
+// PatchExample changes the given fields of the example with the given id,
+// with the same version semantics as UpdateExample.
+func (s Service) PatchExample(ctx context.Context, id uuid.UUID, patch ExamplePatchDTO, version *int) (_ Example, err error) {
+	ctx, span := startSpan(ctx, "PatchExample", exampleIDAttribute(id.String()))
+	defer endSpan(span, &err)
+
+	var ex Example
+	err = s.withTx(ctx, func(ctx context.Context) error {
+		current, err := s.ExampleRepository.FindOneByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("could not get example by id: %s, %w", id, err)
+		}
+
+		// Without an expected version the patch is applied on top of what
+		// was just read, still guarding against concurrent changes in
+		// between.
+		expected := version
+		if expected == nil {
+			expected = &current.Version
+		}
+
+		ex, err = s.update(ctx, current, patch.apply(current), expected)
+		if err != nil {
+			return err
+		}
+
+		return s.recordEvent(ctx, EventExampleUpdated, ex)
+	})
+	if err != nil {
+		return Example{}, err
+	}
+
+	s.logChange(ctx, EventExampleUpdated, ex.ID)
+
+	return ex, nil
+}
+
+func (s Service) update(ctx context.Context, current Example, dto ExampleDTO, version *int) (Example, error) {
+	if err := authorizeOwner(ctx, current); err != nil {
+		return Example{}, err
+	}
+
+	if err := dto.Validate(); err != nil {
+		return Example{}, err
+	}
+
+	updated := current
+	updated.Name = dto.Name
+
+	ex, err := s.ExampleRepository.Update(ctx, updated, version)
+	if err != nil {
+		return Example{}, fmt.Errorf("could not update example %s: %w", current.ID, err)
+	}
+
+	return ex, nil
+}
+
+// DeleteExample deletes the example with the given id. The delete is only
+// performed if version matches the stored version, otherwise ErrConflict is
+// returned. A nil version deletes unconditionally.
+func (s Service) DeleteExample(ctx context.Context, id uuid.UUID, version *int) (err error) {
+	ctx, span := startSpan(ctx, "DeleteExample", exampleIDAttribute(id.String()))
+	defer endSpan(span, &err)
+
+	err = s.withTx(ctx, func(ctx context.Context) error {
+		current, err := s.ExampleRepository.FindOneByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("could not get example by id: %s, %w", id, err)
+		}
+
+		if err := authorizeOwner(ctx, current); err != nil {
+			return err
+		}
+
+		if err := s.ExampleRepository.Delete(ctx, id, version); err != nil {
+			return fmt.Errorf("could not delete example %s: %w", id, err)
+		}
+
+		return s.recordEvent(ctx, EventExampleDeleted, current)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logChange(ctx, EventExampleDeleted, id)
+
+	return nil
+}
+
+// logChange logs a committed change of the example id, with the fields of
+// the request of ctx.
+func (s Service) logChange(ctx context.Context, t EventType, id uuid.UUID) {
+	logger := s.Logger
+	if logger == nil {
+		logger = logging.FromContext(ctx)
+	}
+
+	logger.InfoCtx(ctx, "example changed",
+		logging.String("change", string(t)),
+		logging.String("exampleID", id.String()),
+	)
+}
+
+// withTx runs fn in a transaction of the TxManager, if any.
+func (s Service) withTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.TxManager == nil {
+		return fn(ctx)
+	}
+
+	return s.TxManager.WithTx(ctx, fn)
+}
+
+// recordEvent records an event of t about ex, if events are enabled.
+func (s Service) recordEvent(ctx context.Context, t EventType, ex Example) error {
+	if s.Events == nil {
+		return nil
+	}
+
+	event, err := newEvent(t, ex)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Events.Append(ctx, event); err != nil {
+		return fmt.Errorf("could not record %s event: %w", t, err)
+	}
+
+	return nil
+}
+
+// authorizeOwner returns ErrForbidden unless the principal of ctx owns ex or
+// has been granted ScopeAdmin.
+func authorizeOwner(ctx context.Context, ex Example) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return WrapError(fmt.Errorf("no principal to authorize"), ErrForbidden)
+	}
+
+	if p.HasScope(ScopeAdmin) || (ex.Owner != "" && ex.Owner == p.ID()) {
+		return nil
+	}
+
+	return WrapError(fmt.Errorf("%s does not own example %s", p.ID(), ex.ID), ErrForbidden)
+}
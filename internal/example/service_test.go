@@ -3,10 +3,13 @@ package example
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/auth"
 )
 
 func TestGetExample(t *testing.T) {
@@ -74,3 +77,470 @@ func TestGetExample(t *testing.T) {
 	}
 
 }
+
+func TestListExamples(t *testing.T) {
+	// Arrange
+	now := time.Now().UTC()
+
+	var examples []Example
+	for i := 0; i < 3; i++ {
+		examples = append(examples, Example{
+			ID:        uuid.New(),
+			Name:      "Test",
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	repo := &exampleRepositoryMock{
+		ListFunc: func(ctx context.Context, q ListQuery) ([]Example, error) {
+			start := 0
+			if q.After != nil {
+				for i, ex := range examples {
+					if ex.ID == q.After.ID {
+						start = i + 1
+					}
+				}
+			}
+
+			end := start + q.Limit
+			if end > len(examples) {
+				end = len(examples)
+			}
+
+			return examples[start:end], nil
+		},
+	}
+
+	s := Service{
+		ExampleRepository: repo,
+	}
+
+	// Act
+	first, err := s.ListExamples(context.Background(), ListParams{Limit: 2})
+	require.NoError(t, err)
+
+	second, err := s.ListExamples(context.Background(), ListParams{Limit: 2, Cursor: first.NextCursor})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, examples[:2], first.Examples)
+	assert.NotEmpty(t, first.NextCursor)
+
+	assert.Equal(t, examples[2:], second.Examples)
+	assert.Empty(t, second.NextCursor)
+
+	calls := repo.ListCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, 3, calls[0].Q.Limit, "should fetch one extra to detect next page")
+	assert.Equal(t, NameMatchPrefix, calls[0].Q.NameMatch)
+	assert.Equal(t, SortAsc, calls[0].Q.Order)
+	assert.Nil(t, calls[0].Q.After)
+	require.NotNil(t, calls[1].Q.After)
+	assert.Equal(t, examples[1].ID, calls[1].Q.After.ID)
+}
+
+func TestListExamplesValidation(t *testing.T) {
+	// Arrange
+	s := Service{
+		ExampleRepository: &exampleRepositoryMock{},
+	}
+
+	descCursor := Cursor{ID: uuid.New(), Order: SortDesc}.Encode()
+
+	tests := []struct {
+		name   string
+		params ListParams
+	}{
+		{
+			name:   "should return error on too large limit",
+			params: ListParams{Limit: MaxListLimit + 1},
+		},
+		{
+			name:   "should return error on negative limit",
+			params: ListParams{Limit: -1},
+		},
+		{
+			name:   "should return error on invalid sort order",
+			params: ListParams{Order: "sideways"},
+		},
+		{
+			name:   "should return error on invalid name match",
+			params: ListParams{NameMatch: "fuzzy"},
+		},
+		{
+			name:   "should return error on malformed cursor",
+			params: ListParams{Cursor: "not a cursor"},
+		},
+		{
+			name:   "should return error on cursor from other sort order",
+			params: ListParams{Order: SortAsc, Cursor: descCursor},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := s.ListExamples(context.Background(), tt.params)
+
+			// Assert
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrValidation)
+		})
+	}
+}
+
+func TestUpdateExample(t *testing.T) {
+	// Arrange
+	existing := Example{
+		ID:      uuid.New(),
+		Name:    "Test",
+		Version: 2,
+		Owner:   "basic:owner",
+	}
+
+	repo := &exampleRepositoryMock{
+		FindOneByIDFunc: func(ctx context.Context, id uuid.UUID) (Example, error) {
+			if id != existing.ID {
+				return Example{}, ErrNotFound
+			}
+
+			return existing, nil
+		},
+		UpdateFunc: func(ctx context.Context, ex Example, expectedVersion *int) (Example, error) {
+			if ex.ID != existing.ID {
+				return Example{}, ErrNotFound
+			}
+
+			if expectedVersion != nil && *expectedVersion != existing.Version {
+				return Example{}, ErrConflict
+			}
+
+			ex.Version = existing.Version + 1
+			return ex, nil
+		},
+	}
+
+	s := Service{
+		ExampleRepository: repo,
+	}
+
+	var (
+		current, stale = existing.Version, existing.Version - 1
+
+		owner = auth.Principal{Subject: "owner", Method: auth.MethodBasic, Scopes: []string{ScopeWrite}}
+		other = auth.Principal{Subject: "other", Method: auth.MethodBasic, Scopes: []string{ScopeWrite}}
+		admin = auth.Principal{Subject: "admin", Method: auth.MethodBasic, Scopes: []string{ScopeWrite, ScopeAdmin}}
+	)
+
+	tests := []struct {
+		name           string
+		givenPrincipal auth.Principal
+		givenID        uuid.UUID
+		givenDTO       ExampleDTO
+		givenVersion   *int
+		expected       Example
+		expectedError  error
+	}{
+		{
+			name:           "should return error on invalid example",
+			givenPrincipal: owner,
+			givenID:        existing.ID,
+			givenDTO:       ExampleDTO{},
+			givenVersion:   &current,
+			expectedError:  ErrValidation,
+		},
+		{
+			name:           "should return error on non existing example",
+			givenPrincipal: owner,
+			givenID:        uuid.New(),
+			givenDTO:       ExampleDTO{Name: "Updated"},
+			givenVersion:   &current,
+			expectedError:  ErrNotFound,
+		},
+		{
+			name:           "should return conflict on stale version",
+			givenPrincipal: owner,
+			givenID:        existing.ID,
+			givenDTO:       ExampleDTO{Name: "Updated"},
+			givenVersion:   &stale,
+			expectedError:  ErrConflict,
+		},
+		{
+			name:           "should return forbidden when not owner",
+			givenPrincipal: other,
+			givenID:        existing.ID,
+			givenDTO:       ExampleDTO{Name: "Updated"},
+			givenVersion:   &current,
+			expectedError:  ErrForbidden,
+		},
+		{
+			name:           "should return updated example",
+			givenPrincipal: owner,
+			givenID:        existing.ID,
+			givenDTO:       ExampleDTO{Name: "Updated"},
+			givenVersion:   &current,
+			expected:       Example{ID: existing.ID, Name: "Updated", Version: 3, Owner: "basic:owner"},
+		},
+		{
+			name:           "should let admin update example of others",
+			givenPrincipal: admin,
+			givenID:        existing.ID,
+			givenDTO:       ExampleDTO{Name: "Updated"},
+			givenVersion:   &current,
+			expected:       Example{ID: existing.ID, Name: "Updated", Version: 3, Owner: "basic:owner"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := auth.WithPrincipal(context.Background(), tt.givenPrincipal)
+
+			// Act
+			got, err := s.UpdateExample(ctx, tt.givenID, tt.givenDTO, tt.givenVersion)
+
+			// Assert
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestPatchExample(t *testing.T) {
+	// Arrange
+	existing := Example{
+		ID:      uuid.New(),
+		Name:    "Test",
+		Version: 2,
+		Owner:   "basic:owner",
+	}
+
+	repo := &exampleRepositoryMock{
+		FindOneByIDFunc: func(ctx context.Context, id uuid.UUID) (Example, error) {
+			return existing, nil
+		},
+		UpdateFunc: func(ctx context.Context, ex Example, expectedVersion *int) (Example, error) {
+			ex.Version = *expectedVersion + 1
+			return ex, nil
+		},
+	}
+
+	s := Service{
+		ExampleRepository: repo,
+	}
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{Subject: "owner", Method: auth.MethodBasic})
+	name := "Patched"
+
+	// Act
+	got, err := s.PatchExample(ctx, existing.ID, ExamplePatchDTO{Name: &name}, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Patched", got.Name)
+
+	calls := repo.UpdateCalls()
+	require.Len(t, calls, 1)
+	require.NotNil(t, calls[0].ExpectedVersion, "should guard against changes since read")
+	assert.Equal(t, existing.Version, *calls[0].ExpectedVersion)
+}
+
+func TestUpdateExampleInTransaction(t *testing.T) {
+	// Arrange
+	type txKey struct{}
+
+	existing := Example{ID: uuid.New(), Name: "Test", Version: 1, Owner: "basic:owner"}
+
+	repo := &exampleRepositoryMock{
+		FindOneByIDFunc: func(ctx context.Context, id uuid.UUID) (Example, error) {
+			assert.NotNil(t, ctx.Value(txKey{}), "should read in transaction")
+			return existing, nil
+		},
+		UpdateFunc: func(ctx context.Context, ex Example, expectedVersion *int) (Example, error) {
+			assert.NotNil(t, ctx.Value(txKey{}), "should write in transaction")
+			return ex, nil
+		},
+	}
+
+	tx := &txManagerMock{
+		WithTxFunc: func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(context.WithValue(ctx, txKey{}, true))
+		},
+	}
+
+	s := Service{
+		ExampleRepository: repo,
+		TxManager:         tx,
+	}
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{Subject: "owner", Method: auth.MethodBasic})
+
+	// Act
+	_, err := s.UpdateExample(ctx, existing.ID, ExampleDTO{Name: "Updated"}, &existing.Version)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, tx.WithTxCalls(), 1)
+	assert.Len(t, repo.UpdateCalls(), 1)
+}
+
+func TestDeleteExample(t *testing.T) {
+	// Arrange
+	repo := &exampleRepositoryMock{
+		FindOneByIDFunc: func(ctx context.Context, id uuid.UUID) (Example, error) {
+			return Example{ID: id, Owner: "basic:owner"}, nil
+		},
+		DeleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersion *int) error {
+			return ErrConflict
+		},
+	}
+
+	s := Service{
+		ExampleRepository: repo,
+	}
+
+	version := 1
+
+	tests := []struct {
+		name           string
+		givenPrincipal auth.Principal
+		expectedError  error
+	}{
+		{
+			name:           "should return forbidden when not owner",
+			givenPrincipal: auth.Principal{Subject: "other", Method: auth.MethodBasic},
+			expectedError:  ErrForbidden,
+		},
+		{
+			name:           "should return conflict from repository",
+			givenPrincipal: auth.Principal{Subject: "owner", Method: auth.MethodBasic},
+			expectedError:  ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := auth.WithPrincipal(context.Background(), tt.givenPrincipal)
+
+			// Act
+			err := s.DeleteExample(ctx, uuid.New(), &version)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestExamplesAreOwnedPerAuthMethod(t *testing.T) {
+	var (
+		basic = auth.Principal{Subject: "alice", Method: auth.MethodBasic, Scopes: []string{ScopeWrite}}
+		jwt   = auth.Principal{Subject: "alice", Method: auth.MethodJWT, Scopes: []string{ScopeWrite}}
+	)
+
+	tests := []struct {
+		name           string
+		givenOwner     auth.Principal
+		givenPrincipal auth.Principal
+		expectedError  error
+	}{
+		{
+			name:           "should let owner delete example",
+			givenOwner:     jwt,
+			givenPrincipal: jwt,
+		},
+		{
+			name:           "should return forbidden to jwt principal with subject of basic owner",
+			givenOwner:     basic,
+			givenPrincipal: jwt,
+			expectedError:  ErrForbidden,
+		},
+		{
+			name:           "should return forbidden to basic principal with subject of jwt owner",
+			givenOwner:     jwt,
+			givenPrincipal: basic,
+			expectedError:  ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := &exampleRepositoryMock{
+				FindOneByIDFunc: func(ctx context.Context, id uuid.UUID) (Example, error) {
+					return Example{ID: id, Version: 1, Owner: tt.givenOwner.ID()}, nil
+				},
+				DeleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersion *int) error {
+					return nil
+				},
+			}
+
+			s := Service{
+				ExampleRepository: repo,
+				Events: &eventStoreMock{
+					AppendFunc: func(ctx context.Context, events ...Event) error {
+						return nil
+					},
+				},
+			}
+
+			ctx := auth.WithPrincipal(context.Background(), tt.givenPrincipal)
+
+			// Act
+			err := s.DeleteExample(ctx, uuid.New(), nil)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Empty(t, repo.DeleteCalls())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, repo.DeleteCalls(), 1)
+		})
+	}
+}
+
+func TestCreateExample(t *testing.T) {
+	// Arrange
+	repo := &exampleRepositoryMock{
+		SaveFunc: func(ctx context.Context, ex Example) error {
+			return nil
+		},
+	}
+
+	events := &eventStoreMock{
+		AppendFunc: func(ctx context.Context, events ...Event) error {
+			return nil
+		},
+	}
+
+	s := Service{
+		ExampleRepository: repo,
+		Events:            events,
+	}
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{Subject: "owner", Method: auth.MethodBasic})
+
+	// Act
+	got, err := s.CreateExample(ctx, ExampleDTO{Name: "Test"})
+	_, noPrincipalErr := s.CreateExample(context.Background(), ExampleDTO{Name: "Test"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "basic:owner", got.Owner)
+	assert.Equal(t, 1, got.Version)
+	assert.ErrorIs(t, noPrincipalErr, ErrForbidden)
+
+	calls := events.AppendCalls()
+	require.Len(t, calls, 1)
+	require.Len(t, calls[0].Events, 1)
+	assert.Equal(t, EventExampleCreated, calls[0].Events[0].Type)
+	assert.Equal(t, got.ID, calls[0].Events[0].ExampleID)
+	assert.NotEqual(t, uuid.Nil, calls[0].Events[0].ID)
+}
@@ -0,0 +1,44 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/bratteby/go-service-template/internal/example")
+
+// startSpan starts the span of a service method, it is ended by endSpan.
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "example.Service/"+method, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, recording *err if set. Only server errors mark the span
+// as failed, client errors such as ErrNotFound are expected outcomes.
+func endSpan(span trace.Span, err *error) {
+	defer span.End()
+
+	if *err == nil {
+		return
+	}
+
+	span.RecordError(*err)
+
+	var apiErr APIError
+	if errors.As(*err, &apiErr) {
+		if status, _ := apiErr.APIError(); status < http.StatusInternalServerError {
+			return
+		}
+	}
+
+	span.SetStatus(codes.Error, (*err).Error())
+}
+
+func exampleIDAttribute(id string) attribute.KeyValue {
+	return attribute.String("example.id", id)
+}
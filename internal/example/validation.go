@@ -0,0 +1,60 @@
+package example
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is a validation failure of a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError accumulates field errors so all problems of a request are
+// reported at once. It is an APIError matching ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// InvalidField returns a validation error of a single field.
+func InvalidField(field, format string, args ...any) error {
+	var v ValidationError
+	v.Add(field, format, args...)
+
+	return v.Err()
+}
+
+// Add adds a failure of field.
+func (e *ValidationError) Add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Err returns e if any field failed, otherwise nil.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = fmt.Sprintf("%s: %s", f.Field, f.Message)
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(err error) bool {
+	return err == ErrValidation
+}
+
+func (e *ValidationError) APIError() (int, string) {
+	return ErrValidation.APIError()
+}
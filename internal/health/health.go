@@ -0,0 +1,218 @@
+// Package health reports the liveness and readiness of the service through
+// a registry of named checks.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout is the timeout of a single check when the registry has none.
+const DefaultTimeout = 2 * time.Second
+
+// ErrShuttingDown fails readiness once shutdown has begun.
+var ErrShuttingDown = errors.New("shutting down")
+
+// Checker checks a dependency, a nil error means healthy.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a func to a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Pinger is implemented by e.g. *pgxpool.Pool.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping returns a checker pinging p.
+func Ping(p Pinger) Checker {
+	return CheckerFunc(p.Ping)
+}
+
+// Status of a check or report.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+)
+
+// Report is the outcome of running checks.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Status  Status  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Registry holds the liveness and readiness checks. Liveness checks should
+// only fail when the process needs a restart, while readiness checks cover
+// the dependencies needed to serve requests. The zero value is ready to use.
+type Registry struct {
+	// Timeout of a single check, DefaultTimeout if zero.
+	Timeout time.Duration
+
+	mu           sync.RWMutex
+	liveness     []namedChecker
+	readiness    []namedChecker
+	shuttingDown atomic.Bool
+}
+
+// AddLiveness registers a liveness check.
+func (r *Registry) AddLiveness(name string, c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.liveness = append(r.liveness, namedChecker{name: name, checker: c})
+}
+
+// AddReadiness registers a readiness check.
+func (r *Registry) AddReadiness(name string, c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.readiness = append(r.readiness, namedChecker{name: name, checker: c})
+}
+
+// ShuttingDown makes readiness fail from now on, so load balancers stop
+// routing new requests while in-flight ones are drained.
+func (r *Registry) ShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Live runs the liveness checks.
+func (r *Registry) Live(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.liveness
+	r.mu.RUnlock()
+
+	return r.run(ctx, checks)
+}
+
+// Ready runs the readiness checks, failing regardless once ShuttingDown has
+// been called.
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.readiness
+	r.mu.RUnlock()
+
+	if r.shuttingDown.Load() {
+		checks = append([]namedChecker{{
+			name: "shutdown",
+			checker: CheckerFunc(func(context.Context) error {
+				return ErrShuttingDown
+			}),
+		}}, checks...)
+	}
+
+	return r.run(ctx, checks)
+}
+
+// LiveHandler serves the liveness report.
+func (r *Registry) LiveHandler() http.Handler {
+	return reportHandler(r.Live)
+}
+
+// ReadyHandler serves the readiness report.
+func (r *Registry) ReadyHandler() http.Handler {
+	return reportHandler(r.Ready)
+}
+
+// run runs checks concurrently, each bounded by the registry timeout.
+func (r *Registry) run(ctx context.Context, checks []namedChecker) Report {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]CheckResult, len(checks))
+	)
+
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c namedChecker) {
+			defer wg.Done()
+
+			results[i] = runCheck(ctx, c.checker, timeout)
+		}(i, c)
+	}
+
+	wg.Wait()
+
+	report := Report{
+		Status: StatusPass,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+
+		if results[i].Status == StatusFail {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func runCheck(ctx context.Context, c Checker, timeout time.Duration) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	t1 := time.Now()
+	defer func() {
+		result.Latency = float64(time.Since(t1).Microseconds()) / 1000
+
+		// A panicking check must not take down the probe endpoint.
+		if v := recover(); v != nil {
+			result.Status, result.Error = StatusFail, fmt.Sprintf("check panicked: %v", v)
+		}
+	}()
+
+	if err := c.Check(ctx); err != nil {
+		return CheckResult{Status: StatusFail, Error: err.Error()}
+	}
+
+	return CheckResult{Status: StatusPass}
+}
+
+func reportHandler(run func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+
+		status := http.StatusOK
+		if report.Status == StatusFail {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+
+		json.NewEncoder(w).Encode(report)
+	})
+}
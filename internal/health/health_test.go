@@ -0,0 +1,106 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyHandler(t *testing.T) {
+	pass := CheckerFunc(func(context.Context) error { return nil })
+	fail := CheckerFunc(func(context.Context) error { return errors.New("connection refused") })
+	slow := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	tests := []struct {
+		name           string
+		givenChecks    map[string]Checker
+		givenShutdown  bool
+		expectedStatus int
+		expected       map[string]Status
+	}{
+		{
+			name:           "should pass without checks",
+			expectedStatus: http.StatusOK,
+			expected:       map[string]Status{},
+		},
+		{
+			name:           "should pass when all checks pass",
+			givenChecks:    map[string]Checker{"postgres": pass, "migrations": pass},
+			expectedStatus: http.StatusOK,
+			expected:       map[string]Status{"postgres": StatusPass, "migrations": StatusPass},
+		},
+		{
+			name:           "should fail when a check fails",
+			givenChecks:    map[string]Checker{"postgres": fail, "migrations": pass},
+			expectedStatus: http.StatusServiceUnavailable,
+			expected:       map[string]Status{"postgres": StatusFail, "migrations": StatusPass},
+		},
+		{
+			name:           "should fail when a check times out",
+			givenChecks:    map[string]Checker{"postgres": slow},
+			expectedStatus: http.StatusServiceUnavailable,
+			expected:       map[string]Status{"postgres": StatusFail},
+		},
+		{
+			name:           "should fail when shutting down",
+			givenChecks:    map[string]Checker{"postgres": pass},
+			givenShutdown:  true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expected:       map[string]Status{"postgres": StatusPass, "shutdown": StatusFail},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			r := &Registry{Timeout: 10 * time.Millisecond}
+			for name, c := range tt.givenChecks {
+				r.AddReadiness(name, c)
+			}
+
+			if tt.givenShutdown {
+				r.ShuttingDown()
+			}
+
+			rec := httptest.NewRecorder()
+
+			// Act
+			r.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var report Report
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+
+			got := map[string]Status{}
+			for name, result := range report.Checks {
+				got[name] = result.Status
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestLiveIgnoresShutdown(t *testing.T) {
+	// Arrange
+	r := &Registry{}
+	r.AddReadiness("postgres", CheckerFunc(func(context.Context) error { return errors.New("down") }))
+	r.ShuttingDown()
+
+	// Act
+	report := r.Live(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusPass, report.Status)
+}
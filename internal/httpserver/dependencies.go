@@ -4,10 +4,24 @@ import (
 	"context"
 
 	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/webhook"
 	"github.com/google/uuid"
 )
 
 type exampleService interface {
 	CreateExample(context.Context, example.ExampleDTO) (example.Example, error)
 	GetExampleByID(context.Context, uuid.UUID) (example.Example, error)
+	ListExamples(context.Context, example.ListParams) (example.Page, error)
+	UpdateExample(context.Context, uuid.UUID, example.ExampleDTO, *int) (example.Example, error)
+	PatchExample(context.Context, uuid.UUID, example.ExamplePatchDTO, *int) (example.Example, error)
+	DeleteExample(context.Context, uuid.UUID, *int) error
+}
+
+type webhookService interface {
+	CreateSubscription(context.Context, webhook.SubscriptionDTO) (webhook.Subscription, error)
+	ListSubscriptions(context.Context) ([]webhook.Subscription, error)
+	GetSubscription(context.Context, uuid.UUID) (webhook.Subscription, error)
+	DeleteSubscription(context.Context, uuid.UUID) error
+	ListDeliveries(context.Context, uuid.UUID, int) ([]webhook.Delivery, error)
+	ListAttempts(context.Context, uuid.UUID, uuid.UUID) ([]webhook.Attempt, error)
 }
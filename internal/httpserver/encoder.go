@@ -7,17 +7,31 @@ import (
 	"fmt"
 	"net/http"
 
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/bratteby/go-service-template/internal/auth"
 	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/idempotency"
 	"github.com/bratteby/go-service-template/internal/logging"
+	"github.com/bratteby/go-service-template/internal/ratelimit"
 )
 
 type encoder struct {
 	Logger *logging.Logger
 }
 
-// errorResponse will encapsulate errors to be transferred over HTTP.
-type errorResponse struct {
-	Message string `json:"message"`
+// problemContentType is the media type of problem details, see RFC 7807.
+const problemContentType = "application/problem+json"
+
+// problem will encapsulate errors to be transferred over HTTP as RFC 7807
+// problem details.
+type problem struct {
+	Type     string               `json:"type"`
+	Title    string               `json:"title"`
+	Status   int                  `json:"status"`
+	Detail   string               `json:"detail,omitempty"`
+	Instance string               `json:"instance,omitempty"` // Request ID, to correlate with logs.
+	Errors   []example.FieldError `json:"errors,omitempty"`
 }
 
 func (e encoder) respond(
@@ -35,12 +49,17 @@ func (e encoder) respond(
 	w.WriteHeader(statusCode)
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		e.Logger.Error(fmt.Errorf("error encoding/writing response %w", err))
+		e.Logger.ErrorCtx(ctx, fmt.Errorf("error encoding/writing response %w", err))
 	}
 }
 
 func (e encoder) error(ctx context.Context, w http.ResponseWriter, err error) {
-	e.Logger.ErrorWith(err.Error())
+	if example.IsAlert(err) {
+		// Picked up by log based alerting.
+		e.Logger.ErrorCtx(ctx, err, logging.Bool("alert", true))
+	} else {
+		e.Logger.ErrorCtx(ctx, err)
+	}
 
 	var (
 		apiErr     example.APIError
@@ -56,14 +75,62 @@ func (e encoder) error(ctx context.Context, w http.ResponseWriter, err error) {
 		errorMsg = "internal error"
 	}
 
-	resp := errorResponse{
-		Message: errorMsg,
+	resp := problem{
+		// No problem types are defined beyond the status code.
+		Type:     "about:blank",
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   errorMsg,
+		Instance: chimiddleware.GetReqID(ctx),
 	}
 
-	w.Header().Set("Content-type", "application/json; charset=utf-8")
+	var validationErr *example.ValidationError
+	if errors.As(err, &validationErr) {
+		resp.Errors = validationErr.Fields
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
 	w.WriteHeader(statusCode)
 
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		e.Logger.Error(fmt.Errorf("error encoding/writing response %w", err))
+		e.Logger.ErrorCtx(ctx, fmt.Errorf("error encoding/writing response %w", err))
+	}
+}
+
+// authError responds to failed authentication or authorization, credential
+// errors are mapped to example.ErrAuth and missing scopes to
+// example.ErrForbidden while e.g. an unavailable API key store is not.
+func (e encoder) authError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, auth.ErrNoCredentials), errors.Is(err, auth.ErrInvalidCredentials):
+		err = example.WrapError(err, example.ErrAuth)
+	case errors.Is(err, auth.ErrForbidden):
+		err = example.WrapError(err, example.ErrForbidden)
 	}
+
+	e.error(r.Context(), w, err)
+}
+
+// idempotencyError responds to a request whose Idempotency-Key can't be
+// honored, mapping the errors of the idempotency middleware to API errors.
+func (e encoder) idempotencyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, idempotency.ErrInvalidKey):
+		err = example.InvalidField(idempotency.Header, "must be at most %d characters", idempotency.MaxKeyLength)
+	case errors.Is(err, idempotency.ErrInProgress):
+		err = example.WrapError(err, example.ErrConflict)
+	case errors.Is(err, idempotency.ErrMismatch):
+		err = example.WrapError(err, example.ErrIdempotencyKeyReuse)
+	}
+
+	e.error(r.Context(), w, err)
+}
+
+// rateLimitError responds to a request denied by the rate limiter.
+func (e encoder) rateLimitError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ratelimit.ErrLimited) {
+		err = example.WrapError(err, example.ErrRateLimit)
+	}
+
+	e.error(r.Context(), w, err)
 }
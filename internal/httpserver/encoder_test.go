@@ -0,0 +1,90 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+func TestEncoderError(t *testing.T) {
+	// Arrange
+	e := encoder{
+		Logger: logging.New(io.Discard, logging.Config{}),
+	}
+
+	ctx := context.WithValue(context.Background(), chimiddleware.RequestIDKey, "req-1")
+
+	tests := []struct {
+		name     string
+		givenErr error
+		expected problem
+	}{
+		{
+			name: "should list field errors of validation error",
+			givenErr: example.ListParams{NameMatch: "fuzzy", Order: "sideways", Limit: -1}.
+				Validate(),
+			expected: problem{
+				Type:     "about:blank",
+				Title:    "Bad Request",
+				Status:   http.StatusBadRequest,
+				Detail:   "invalid request",
+				Instance: "req-1",
+				Errors: []example.FieldError{
+					{Field: "name_match", Message: "must be one of [prefix, contains]"},
+					{Field: "sort", Message: "must be one of [asc, desc]"},
+					{Field: "limit", Message: "must be between 1 and 100"},
+				},
+			},
+		},
+		{
+			name:     "should use status of wrapped sentinel",
+			givenErr: example.WrapError(errors.New("no rows"), example.ErrNotFound),
+			expected: problem{
+				Type:     "about:blank",
+				Title:    "Not Found",
+				Status:   http.StatusNotFound,
+				Detail:   "not found",
+				Instance: "req-1",
+			},
+		},
+		{
+			name:     "should hide internal errors",
+			givenErr: errors.New("connection reset by peer"),
+			expected: problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "internal error",
+				Instance: "req-1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.error(ctx, rec, tt.givenErr)
+
+			// Assert
+			assert.Equal(t, tt.expected.Status, rec.Code)
+			assert.Equal(t, problemContentType, rec.Header().Get("Content-Type"))
+
+			var got problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+// etag returns the entity tag of a resource version.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatch is a parsed If-Match request header.
+type ifMatch struct {
+	present bool
+	any     bool // If-Match: *
+	version int
+}
+
+// parseIfMatch parses the If-Match header, only a single strong entity tag
+// or * is supported.
+func parseIfMatch(r *http.Request) (ifMatch, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return ifMatch{}, nil
+	}
+
+	if header == "*" {
+		return ifMatch{present: true, any: true}, nil
+	}
+
+	tag, err := strconv.Unquote(header)
+	if err != nil {
+		return ifMatch{}, fmt.Errorf("must be a single strong entity tag")
+	}
+
+	version, err := strconv.Atoi(tag)
+	if err != nil {
+		return ifMatch{}, fmt.Errorf("entity tag is not a version")
+	}
+
+	return ifMatch{present: true, version: version}, nil
+}
+
+// expectedVersion resolves the expected version of a write. The If-Match
+// header takes precedence over a version in the request body. A nil version
+// means the write is unconditional.
+func (m ifMatch) expectedVersion(bodyVersion *int) *int {
+	switch {
+	case m.any:
+		return nil
+	case m.present:
+		return &m.version
+	default:
+		return bodyVersion
+	}
+}
+
+// versionError maps a version conflict to 412 Precondition Failed when the
+// version came from If-Match, as it then is a failed HTTP precondition
+// rather than a conflicting request body.
+func (m ifMatch) versionError(err error) error {
+	if m.present && errors.Is(err, example.ErrConflict) {
+		return example.WrapError(err, example.ErrPreconditionFailed)
+	}
+
+	return err
+}
@@ -0,0 +1,84 @@
+package httpserver
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	bodyVersion := 7
+
+	tests := []struct {
+		name            string
+		header          string
+		expectedError   bool
+		expectedVersion *int
+	}{
+		{
+			name:            "should fall back to body version without header",
+			header:          "",
+			expectedVersion: &bodyVersion,
+		},
+		{
+			name:            "should be unconditional on wildcard",
+			header:          "*",
+			expectedVersion: nil,
+		},
+		{
+			name:            "should use version of entity tag",
+			header:          etag(3),
+			expectedVersion: func() *int { v := 3; return &v }(),
+		},
+		{
+			name:          "should return error on weak entity tag",
+			header:        `W/"3"`,
+			expectedError: true,
+		},
+		{
+			name:          "should return error on non version entity tag",
+			header:        `"abc"`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			r := httptest.NewRequest("PUT", "/", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+
+			// Act
+			match, err := parseIfMatch(r)
+
+			// Assert
+			if tt.expectedError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedVersion, match.expectedVersion(&bodyVersion))
+		})
+	}
+}
+
+func TestVersionError(t *testing.T) {
+	// Arrange
+	conflict := example.WrapError(assert.AnError, example.ErrConflict)
+
+	// Act
+	withIfMatch := ifMatch{present: true, version: 1}.versionError(conflict)
+	withoutIfMatch := ifMatch{}.versionError(conflict)
+
+	// Assert
+	assert.ErrorIs(t, withIfMatch, example.ErrPreconditionFailed)
+	assert.ErrorIs(t, withoutIfMatch, example.ErrConflict)
+	assert.NotErrorIs(t, withoutIfMatch, example.ErrPreconditionFailed)
+}
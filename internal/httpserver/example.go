@@ -2,8 +2,8 @@ package httpserver
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -14,12 +14,29 @@ import (
 type exampleHandler struct {
 	exampleService exampleService
 	encoder        encoder
+	// authorize returns a middleware requiring the given scopes.
+	authorize func(scopes ...string) func(http.Handler) http.Handler
+	// idempotent replays responses to retries with the same Idempotency-Key.
+	idempotent func(http.Handler) http.Handler
+	// limit rate limits requests by their route.
+	limit func(http.Handler) http.Handler
 }
 
 func (h exampleHandler) GetRoutes() func(r chi.Router) {
+	var (
+		read  = h.authorize(example.ScopeRead)
+		write = h.authorize(example.ScopeWrite)
+	)
+
 	return func(r chi.Router) {
-		r.Post("/", h.createExample)
-		r.Get("/{id}", h.getExample)
+		r = r.With(h.limit)
+
+		r.With(write, h.idempotent).Post("/", h.createExample)
+		r.With(read).Get("/", h.listExamples)
+		r.With(read).Get("/{id}", h.getExample)
+		r.With(write).Put("/{id}", h.updateExample)
+		r.With(write).Patch("/{id}", h.patchExample)
+		r.With(write).Delete("/{id}", h.deleteExample)
 	}
 }
 
@@ -29,11 +46,7 @@ func (h *exampleHandler) createExample(w http.ResponseWriter, r *http.Request) {
 
 	var ex example.ExampleDTO
 	if err := json.NewDecoder(r.Body).Decode(&ex); err != nil {
-		h.encoder.respond(
-			ctx, w,
-			fmt.Errorf("could not decode request body %w", err),
-			http.StatusBadRequest,
-		)
+		h.encoder.error(ctx, w, example.InvalidField("body", "could not be decoded: %v", err))
 		return
 	}
 
@@ -43,6 +56,7 @@ func (h *exampleHandler) createExample(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", etag(res.Version))
 	h.encoder.respond(ctx, w, res, http.StatusOK)
 }
 
@@ -52,7 +66,7 @@ func (h *exampleHandler) getExample(w http.ResponseWriter, r *http.Request) {
 
 	exampleID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		h.encoder.respond(ctx, w, fmt.Errorf("could not parse ID from url"), http.StatusBadRequest)
+		h.encoder.error(ctx, w, example.InvalidField("id", "must be a UUID"))
 		return
 	}
 
@@ -62,5 +76,147 @@ func (h *exampleHandler) getExample(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", etag(res.Version))
+	h.encoder.respond(ctx, w, res, http.StatusOK)
+}
+
+func (h *exampleHandler) listExamples(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	query := r.URL.Query()
+
+	params := example.ListParams{
+		Name:      query.Get("name"),
+		NameMatch: example.NameMatch(query.Get("name_match")),
+		Order:     example.SortOrder(query.Get("sort")),
+		Cursor:    query.Get("cursor"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			h.encoder.error(ctx, w, example.InvalidField("limit", "must be an integer"))
+			return
+		}
+
+		params.Limit = l
+	}
+
+	res, err := h.exampleService.ListExamples(ctx, params)
+	if err != nil {
+		h.encoder.error(ctx, w, err)
+		return
+	}
+
 	h.encoder.respond(ctx, w, res, http.StatusOK)
 }
+
+// updateExampleRequest is the body of a PUT, the expected version is either
+// given in the body or through If-Match.
+type updateExampleRequest struct {
+	example.ExampleDTO
+	Version *int `json:"version"`
+}
+
+func (h *exampleHandler) updateExample(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	exampleID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("id", "must be a UUID"))
+		return
+	}
+
+	match, err := parseIfMatch(r)
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("If-Match", "%v", err))
+		return
+	}
+
+	var req updateExampleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("body", "could not be decoded: %v", err))
+		return
+	}
+
+	// Replacing without knowing what is replaced would silently overwrite
+	// concurrent changes, so the version must be given explicitly.
+	if !match.present && req.Version == nil {
+		h.encoder.error(ctx, w, example.InvalidField("version", "is required, through If-Match or body"))
+		return
+	}
+
+	res, err := h.exampleService.UpdateExample(ctx, exampleID, req.ExampleDTO, match.expectedVersion(req.Version))
+	if err != nil {
+		h.encoder.error(ctx, w, match.versionError(err))
+		return
+	}
+
+	w.Header().Set("ETag", etag(res.Version))
+	h.encoder.respond(ctx, w, res, http.StatusOK)
+}
+
+// patchExampleRequest is the body of a PATCH, the expected version is either
+// given in the body or through If-Match.
+type patchExampleRequest struct {
+	example.ExamplePatchDTO
+	Version *int `json:"version"`
+}
+
+func (h *exampleHandler) patchExample(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	exampleID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("id", "must be a UUID"))
+		return
+	}
+
+	match, err := parseIfMatch(r)
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("If-Match", "%v", err))
+		return
+	}
+
+	var req patchExampleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("body", "could not be decoded: %v", err))
+		return
+	}
+
+	res, err := h.exampleService.PatchExample(ctx, exampleID, req.ExamplePatchDTO, match.expectedVersion(req.Version))
+	if err != nil {
+		h.encoder.error(ctx, w, match.versionError(err))
+		return
+	}
+
+	w.Header().Set("ETag", etag(res.Version))
+	h.encoder.respond(ctx, w, res, http.StatusOK)
+}
+
+func (h *exampleHandler) deleteExample(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	exampleID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("id", "must be a UUID"))
+		return
+	}
+
+	match, err := parseIfMatch(r)
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("If-Match", "%v", err))
+		return
+	}
+
+	if err := h.exampleService.DeleteExample(ctx, exampleID, match.expectedVersion(nil)); err != nil {
+		h.encoder.error(ctx, w, match.versionError(err))
+		return
+	}
+
+	h.encoder.respond(ctx, w, nil, http.StatusNoContent)
+}
@@ -0,0 +1,142 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+// ScopeLogAdmin allows reading and changing log levels at runtime.
+const ScopeLogAdmin = "log:admin"
+
+type logLevelHandler struct {
+	levels  *logging.Levels
+	encoder encoder
+	// authorize authenticates requests and requires ScopeLogAdmin.
+	authorize func(http.Handler) http.Handler
+}
+
+// logLevelRequest changes a level, temporarily if RevertAfter is set.
+type logLevelRequest struct {
+	Level       *logging.Level `json:"level"`
+	RevertAfter string         `json:"revertAfter,omitempty"` // Duration such as "10m".
+}
+
+type logLevelsResponse struct {
+	Levels []logging.LevelStatus `json:"levels"`
+}
+
+func (h logLevelHandler) GetRoutes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Use(h.authorize)
+
+		r.Get("/", h.getLevels)
+		r.Put("/", h.setLevel)
+		r.Put("/{subsystem}", h.setLevel)
+		r.Delete("/{subsystem}", h.inheritLevel)
+	}
+}
+
+func (h *logLevelHandler) getLevels(w http.ResponseWriter, r *http.Request) {
+	h.encoder.respond(r.Context(), w, logLevelsResponse{Levels: h.levels.Status()}, http.StatusOK)
+}
+
+// setLevel sets the level of the subsystem of the path, the root level if
+// none.
+func (h *logLevelHandler) setLevel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	var req logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("body", "could not be decoded: %v", err))
+		return
+	}
+
+	if req.Level == nil {
+		h.encoder.error(ctx, w, example.InvalidField("level", "is required"))
+		return
+	}
+
+	revertAfter, err := parseRevertAfter(req.RevertAfter)
+	if err != nil {
+		h.encoder.error(ctx, w, err)
+		return
+	}
+
+	status, err := h.levels.SetLevel(chi.URLParam(r, "subsystem"), *req.Level, revertAfter)
+	if err != nil {
+		h.encoder.error(ctx, w, levelError(err))
+		return
+	}
+
+	h.logChange(r, status)
+	h.encoder.respond(ctx, w, status, http.StatusOK)
+}
+
+// inheritLevel makes the subsystem of the path follow the root level again.
+func (h *logLevelHandler) inheritLevel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	revertAfter, err := parseRevertAfter(r.URL.Query().Get("revertAfter"))
+	if err != nil {
+		h.encoder.error(ctx, w, err)
+		return
+	}
+
+	status, err := h.levels.Inherit(chi.URLParam(r, "subsystem"), revertAfter)
+	if err != nil {
+		h.encoder.error(ctx, w, levelError(err))
+		return
+	}
+
+	h.logChange(r, status)
+	h.encoder.respond(ctx, w, status, http.StatusOK)
+}
+
+// logChange logs the changed level with the principal changing it.
+func (h *logLevelHandler) logChange(r *http.Request, status logging.LevelStatus) {
+	subsystem := status.Subsystem
+	if subsystem == "" {
+		subsystem = "root"
+	}
+
+	fields := []logging.Field{
+		logging.String("subsystem", subsystem),
+		logging.String("level", status.Level.String()),
+		logging.Bool("inherited", status.Inherited),
+	}
+	if status.RevertAt != nil {
+		fields = append(fields, logging.String("revertAt", status.RevertAt.Format(time.RFC3339)))
+	}
+
+	h.encoder.Logger.InfoCtx(r.Context(), "log level changed", fields...)
+}
+
+func parseRevertAfter(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, example.InvalidField("revertAfter", "must be a positive duration such as 10m")
+	}
+
+	return d, nil
+}
+
+func levelError(err error) error {
+	if errors.Is(err, logging.ErrUnknownSubsystem) {
+		return example.WrapError(err, example.ErrNotFound)
+	}
+
+	return example.WrapError(err, example.ErrValidation)
+}
@@ -0,0 +1,124 @@
+package httpserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		user           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "should require authentication",
+			method:         http.MethodGet,
+			path:           "/log/levels",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "should require scope",
+			method:         http.MethodGet,
+			path:           "/log/levels",
+			user:           "reader",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "should list levels",
+			method:         http.MethodGet,
+			path:           "/log/levels",
+			user:           "admin",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"levels":[{"level":"info"},{"subsystem":"http","level":"error"}]}`,
+		},
+		{
+			name:           "should set root level",
+			method:         http.MethodPut,
+			path:           "/log/levels",
+			body:           `{"level":"debug"}`,
+			user:           "admin",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"level":"debug"}`,
+		},
+		{
+			name:           "should inherit root level",
+			method:         http.MethodDelete,
+			path:           "/log/levels/http",
+			user:           "admin",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"subsystem":"http","level":"info","inherited":true}`,
+		},
+		{
+			name:           "should reject unknown subsystem",
+			method:         http.MethodPut,
+			path:           "/log/levels/unknown",
+			body:           `{"level":"debug"}`,
+			user:           "admin",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "should require level",
+			method:         http.MethodPut,
+			path:           "/log/levels/http",
+			body:           `{"revertAfter":"10m"}`,
+			user:           "admin",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "should reject invalid revert after",
+			method:         http.MethodPut,
+			path:           "/log/levels/http",
+			body:           `{"level":"debug","revertAfter":"-1m"}`,
+			user:           "admin",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			logger := logging.New(io.Discard, logging.Config{
+				Level:  logging.InfoLevel,
+				Levels: map[string]logging.Level{"http": logging.ErrorLevel},
+			})
+
+			s := &Server{
+				Logger: logger.Named("http"),
+				Authenticators: []auth.Authenticator{auth.BasicAuthenticator{
+					Credentials: map[string]string{"admin": "secret", "reader": "secret"},
+					Roles:       map[string][]string{"admin": {"admin"}},
+				}},
+				AuthPolicy: auth.Policy{Roles: map[string][]string{"admin": {ScopeLogAdmin}}},
+				LogLevels:  logger.Levels(),
+			}
+
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.user != "" {
+				r.SetBasicAuth(tt.user, "secret")
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			s.setupAdminHandler().ServeHTTP(w, r)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
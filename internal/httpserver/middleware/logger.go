@@ -12,6 +12,7 @@ import (
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/bratteby/go-service-template/internal/auth"
 	"github.com/bratteby/go-service-template/internal/logging"
 )
 
@@ -52,7 +53,12 @@ func RequestLogger(logger logging.Logger, opts *RequestLoggerOptions) func(next
 				entry.Write(ww.Status(), ww.BytesWritten(), ww.Header(), time.Since(t1), respBody)
 			}()
 
-			next.ServeHTTP(ww, chimiddleware.WithLogEntry(r, entry))
+			// Logs of the handlers made with the Ctx methods of the logger
+			// carry the request ID too.
+			ctx := logging.WithFields(r.Context(), logging.String("requestID", chimiddleware.GetReqID(r.Context())))
+			ctx = logging.WithContext(ctx, &logger)
+
+			next.ServeHTTP(ww, chimiddleware.WithLogEntry(r.WithContext(ctx), entry))
 		}
 
 		return http.HandlerFunc(fn)
@@ -68,7 +74,7 @@ type requestLogger struct {
 func (l *requestLogger) NewLogEntry(r *http.Request) chimiddleware.LogEntry {
 	entry := &RequestLoggerEntry{}
 	msg := fmt.Sprintf("Request: %s %s", r.Method, r.URL.Path)
-	entry.Logger = l.Logger.With(requestLogFields(r)...)
+	entry.Logger = l.Logger.WithTrace(r.Context()).With(requestLogFields(r)...)
 
 	if defaultLoggerOptions.Verbose {
 		entry.Logger.Info(msg)
@@ -231,7 +237,7 @@ func getHeaderLogField(header http.Header) map[string]string {
 		default:
 			headerField[k] = fmt.Sprintf("[%s]", strings.Join(v, "], ["))
 		}
-		if k == "authorization" || k == "cookie" || k == "set-cookie" {
+		if _, ok := redactedHeaders[k]; ok {
 			headerField[k] = "***"
 		}
 
@@ -239,6 +245,15 @@ func getHeaderLogField(header http.Header) map[string]string {
 	return headerField
 }
 
+// redactedHeaders are the lower-cased headers carrying credentials, whose
+// values are never logged.
+var redactedHeaders = map[string]struct{}{
+	"authorization":                    {},
+	"cookie":                           {},
+	"set-cookie":                       {},
+	strings.ToLower(auth.APIKeyHeader): {},
+}
+
 func statusLevel(status int) logging.Level {
 	switch {
 	case status >= 200 && status < 400:
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+func TestRequestLoggerRedactsCredentials(t *testing.T) {
+	tests := []struct {
+		header string
+		value  string
+	}{
+		{header: "Authorization", value: "Bearer token"},
+		{header: "Cookie", value: "session=secret"},
+		{header: "Set-Cookie", value: "session=secret"},
+		{header: auth.APIKeyHeader, value: "secret-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			// Arrange
+			var buf bytes.Buffer
+			logger := logging.New(&buf, logging.Config{})
+
+			handler := RequestLogger(*logger, &RequestLoggerOptions{Verbose: true})(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set(tt.header, r.Header.Get(tt.header))
+				}),
+			)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(tt.header, tt.value)
+
+			// Act
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			// Assert
+			var logged []string
+			scanner := bufio.NewScanner(&buf)
+			for scanner.Scan() {
+				var entry struct {
+					Request  *struct{ Header map[string]string } `json:"httpRequest"`
+					Response *struct{ Header map[string]string } `json:"httpResponse"`
+				}
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+
+				if entry.Request != nil {
+					logged = append(logged, entry.Request.Header[strings.ToLower(tt.header)])
+				}
+				if entry.Response != nil {
+					logged = append(logged, entry.Response.Header[strings.ToLower(tt.header)])
+				}
+			}
+
+			// The request is logged with both entries, the response with the
+			// last one.
+			assert.Equal(t, []string{"***", "***", "***"}, logged)
+			assert.NotContains(t, buf.String(), tt.value)
+		})
+	}
+}
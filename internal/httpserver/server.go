@@ -1,66 +1,268 @@
 package httpserver
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/health"
 	"github.com/bratteby/go-service-template/internal/httpserver/middleware"
+	"github.com/bratteby/go-service-template/internal/idempotency"
 	"github.com/bratteby/go-service-template/internal/logging"
+	"github.com/bratteby/go-service-template/internal/metrics"
+	"github.com/bratteby/go-service-template/internal/ratelimit"
+	"github.com/bratteby/go-service-template/internal/tracing"
 )
 
 type Server struct {
 	Address        string
 	ExampleService exampleService
+	// WebhookService serves /api/webhooks, nil disables the endpoints.
+	WebhookService webhookService
 	Logger         *logging.Logger
+	// Authenticators of API requests, tried in order.
+	Authenticators []auth.Authenticator
+	// AuthPolicy grants scopes to authenticated principals by their roles.
+	AuthPolicy auth.Policy
+	// IdempotencyStore stores the responses replayed to requests with an
+	// Idempotency-Key for IdempotencyTTL, nil ignores the header. Keys of
+	// requests in progress are locked for IdempotencyLockTimeout.
+	IdempotencyStore       idempotency.Store
+	IdempotencyTTL         time.Duration
+	IdempotencyLockTimeout time.Duration
+	// RateLimitStore stores the buckets of clients limited by
+	// RateLimitRules, nil disables rate limiting. The IP limit of the rules
+	// applies to every API request before authentication.
+	RateLimitStore ratelimit.Store
+	RateLimitRules ratelimit.Rules
+
+	// AdminAddress is the address of a separate listener for admin endpoints
+	// such as /metrics. When empty they are served on Address.
+	AdminAddress string
+	// MetricsHandler serves /metrics, nil disables the endpoint.
+	MetricsHandler http.Handler
+	// LogLevels are read and changed on /log/levels by principals granted
+	// ScopeLogAdmin, nil disables the endpoint.
+	LogLevels *logging.Levels
+	// HTTPMetrics instruments requests, nil disables instrumentation.
+	HTTPMetrics *metrics.HTTPMetrics
+	// Health serves /livez and /readyz, nil reports healthy without checks.
+	Health *health.Registry
+
+	// Timeouts of the underlying http.Server, a zero value means no timeout.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	once    sync.Once
+	servers []*http.Server
 }
 
+// Start will start listening for requests on the server address, and the
+// admin address if set. It blocks until a listener fails or all are shut
+// down, in which case nil is returned.
 func (s *Server) Start() error {
+	servers := s.httpServers()
+
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			err := srv.ListenAndServe()
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
 
-	r := s.setupHandler()
+			errs <- err
+		}(srv)
+	}
+
+	for range servers {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Shutdown gracefully shuts down the server by closing the listeners and
+// waiting for in-flight requests to finish. If ctx expires before all
+// connections are drained the context error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for _, srv := range s.httpServers() {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+
+			if err := srv.Shutdown(ctx); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(srv)
+	}
 
-	// TODO: Setup server with timeouts etc.
-	// s := http.Server {
-	// 	Addr: s.Address,
-	// 	Handler: r,
-	//  timeouts...
-	// }
+	wg.Wait()
 
-	return http.ListenAndServe(s.Address, r)
+	return firstErr
+}
+
+// httpServers lazily sets up the http servers so they are shared between
+// Start and Shutdown regardless of which is called first.
+func (s *Server) httpServers() []*http.Server {
+	s.once.Do(func() {
+		newServer := func(address string, handler http.Handler) *http.Server {
+			return &http.Server{
+				Addr:              address,
+				Handler:           handler,
+				ReadTimeout:       s.ReadTimeout,
+				ReadHeaderTimeout: s.ReadHeaderTimeout,
+				WriteTimeout:      s.WriteTimeout,
+				IdleTimeout:       s.IdleTimeout,
+			}
+		}
+
+		s.servers = append(s.servers, newServer(s.Address, s.setupHandler()))
+
+		if s.AdminAddress != "" {
+			s.servers = append(s.servers, newServer(s.AdminAddress, s.setupAdminHandler()))
+		}
+	})
+
+	return s.servers
 }
 
 // setupHandler will setup all routes and return the http handler.
-func (s Server) setupHandler() http.Handler {
+func (s *Server) setupHandler() http.Handler {
 	r := chi.NewRouter()
 
 	// Middlewares.
 	r.Use(chimiddleware.RequestID)
+	if s.HTTPMetrics != nil {
+		r.Use(s.HTTPMetrics.Middleware)
+	}
+	r.Use(tracing.Middleware)
 	r.Use(middleware.RequestLogger(*s.Logger, &middleware.RequestLoggerOptions{
 		Verbose: true,
 	}))
 	r.Use(chimiddleware.Recoverer)
 
-	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("healthy"))
-	})
+	checks := s.Health
+	if checks == nil {
+		checks = &health.Registry{}
+	}
+
+	r.Method(http.MethodGet, "/livez", checks.LiveHandler())
+	r.Method(http.MethodGet, "/readyz", checks.ReadyHandler())
+	// Kept for existing probes, reports liveness.
+	r.Method(http.MethodGet, "/healthz", checks.LiveHandler())
+
+	if s.AdminAddress == "" {
+		s.adminRoutes(r)
+	}
 
 	e := encoder{
 		Logger: s.Logger,
 	}
 
+	limit := func(next http.Handler) http.Handler {
+		return next
+	}
+	limitIP := limit
+
+	if s.RateLimitStore != nil {
+		limit = ratelimit.Middleware(s.RateLimitStore, s.RateLimitRules, s.Logger, e.rateLimitError)
+
+		if s.RateLimitRules.IP.Requests > 0 {
+			limitIP = ratelimit.IPMiddleware(s.RateLimitStore, s.RateLimitRules, s.Logger, e.rateLimitError)
+		}
+	}
+
 	exampleHandler := exampleHandler{
 		exampleService: s.ExampleService,
 		encoder:        e,
+		authorize: func(scopes ...string) func(http.Handler) http.Handler {
+			return auth.RequireScopes(e.authError, scopes...)
+		},
+		idempotent: func(next http.Handler) http.Handler {
+			return next
+		},
+		limit: limit,
+	}
+
+	if s.IdempotencyStore != nil {
+		exampleHandler.idempotent = idempotency.Middleware(s.IdempotencyStore, s.IdempotencyTTL, s.IdempotencyLockTimeout, s.Logger, e.idempotencyError)
 	}
 
 	r.Route("/api", func(r chi.Router) {
-		r.Use(chimiddleware.BasicAuth("Example", map[string]string{
-			"username": "nOt_saFE_PWD",
-		}))
+		r.Use(limitIP)
+		r.Use(auth.Middleware(s.AuthPolicy, e.authError, s.Authenticators...))
 
 		r.Route("/example", exampleHandler.GetRoutes())
+
+		if s.WebhookService != nil {
+			webhookHandler := webhookHandler{
+				webhookService: s.WebhookService,
+				encoder:        e,
+				authorize:      exampleHandler.authorize,
+				limit:          limit,
+			}
+
+			r.Route("/webhooks", webhookHandler.GetRoutes())
+		}
 	})
 
 	return r
 }
+
+// setupAdminHandler will setup the routes of the admin listener.
+func (s *Server) setupAdminHandler() http.Handler {
+	r := chi.NewRouter()
+
+	r.Use(chimiddleware.Recoverer)
+
+	s.adminRoutes(r)
+
+	return r
+}
+
+// adminRoutes registers the admin endpoints on r.
+func (s *Server) adminRoutes(r chi.Router) {
+	if s.MetricsHandler != nil {
+		r.Method(http.MethodGet, "/metrics", s.MetricsHandler)
+	}
+
+	if s.LogLevels != nil {
+		e := encoder{
+			Logger: s.Logger,
+		}
+
+		authenticate := auth.Middleware(s.AuthPolicy, e.authError, s.Authenticators...)
+		requireScope := auth.RequireScopes(e.authError, ScopeLogAdmin)
+
+		logLevelHandler := logLevelHandler{
+			levels:  s.LogLevels,
+			encoder: e,
+			authorize: func(next http.Handler) http.Handler {
+				return authenticate(requireScope(next))
+			},
+		}
+
+		r.Route("/log/levels", logLevelHandler.GetRoutes())
+	}
+}
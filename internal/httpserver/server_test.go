@@ -0,0 +1,218 @@
+package httpserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/health"
+	"github.com/bratteby/go-service-template/internal/logging"
+	"github.com/bratteby/go-service-template/internal/ratelimit"
+)
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	// Arrange
+	started, release := make(chan struct{}), make(chan struct{})
+	s := newBlockingServer(t, started, release)
+	startErr := start(t, s)
+
+	type result struct {
+		status int
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + s.Address + "/readyz")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		resp.Body.Close()
+		results <- result{status: resp.StatusCode}
+	}()
+	<-started
+
+	// Act
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- s.Shutdown(context.Background())
+	}()
+
+	// Assert
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("shutdown returned before the in-flight request completed: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	res := <-results
+	require.NoError(t, res.err)
+	assert.Equal(t, http.StatusOK, res.status)
+	assert.NoError(t, <-shutdownErr)
+	assert.NoError(t, <-startErr)
+}
+
+func TestShutdownRefusesNewConnections(t *testing.T) {
+	// Arrange
+	s := &Server{
+		Logger:       logging.New(io.Discard, logging.Config{}),
+		AdminAddress: freeAddress(t),
+	}
+	startErr := start(t, s)
+
+	// Act
+	err := s.Shutdown(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.NoError(t, <-startErr)
+
+	for _, address := range []string{s.Address, s.AdminAddress} {
+		_, err := net.DialTimeout("tcp", address, time.Second)
+		assert.Error(t, err, "%s should refuse connections", address)
+	}
+}
+
+func TestShutdownReturnsContextErrorPastDeadline(t *testing.T) {
+	// Arrange
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	s := newBlockingServer(t, started, release)
+	start(t, s)
+
+	go func() {
+		resp, err := http.Get("http://" + s.Address + "/readyz")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Act
+	err := s.Shutdown(ctx)
+
+	// Assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestShutdownIsIdempotent(t *testing.T) {
+	// Arrange
+	s := &Server{
+		Address: freeAddress(t),
+		Logger:  logging.New(io.Discard, logging.Config{}),
+	}
+
+	// Act
+	first := s.Shutdown(context.Background())
+	second := s.Shutdown(context.Background())
+	startErr := s.Start()
+
+	// Assert
+	assert.NoError(t, first)
+	assert.NoError(t, second)
+	assert.NoError(t, startErr, "start after shutdown should return without serving")
+}
+
+// newBlockingServer returns a server whose readiness check signals started
+// and blocks until release is closed.
+func newBlockingServer(t *testing.T, started, release chan struct{}) *Server {
+	t.Helper()
+
+	checks := &health.Registry{Timeout: 10 * time.Second}
+	checks.AddReadiness("blocking", health.CheckerFunc(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}))
+
+	return &Server{
+		Logger: logging.New(io.Discard, logging.Config{}),
+		Health: checks,
+	}
+}
+
+// start starts s on a free address, unless set, and waits until it accepts
+// connections. The returned channel receives the result of Start.
+func start(t *testing.T, s *Server) <-chan error {
+	t.Helper()
+
+	if s.Address == "" {
+		s.Address = freeAddress(t)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.Start()
+	}()
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", s.Address)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond, "server should accept connections")
+
+	return errs
+}
+
+// freeAddress returns a local address that was free when checked.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+func TestUnauthenticatedRequestsAreRateLimited(t *testing.T) {
+	// Arrange
+	s := &Server{
+		Logger: logging.New(io.Discard, logging.Config{}),
+		Authenticators: []auth.Authenticator{auth.BasicAuthenticator{
+			Credentials: map[string]string{"alice": "secret"},
+		}},
+		RateLimitStore: &ratelimit.MemoryStore{},
+		RateLimitRules: ratelimit.Rules{
+			Default: ratelimit.Limit{Requests: 100, Window: time.Minute},
+			IP:      ratelimit.Limit{Requests: 2, Window: time.Minute},
+		},
+	}
+	handler := s.setupHandler()
+
+	send := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/example", nil)
+		r.SetBasicAuth("alice", "wrong")
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// Act
+	first := send()
+	send()
+	limited := send()
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, first.Code)
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.NotEmpty(t, limited.Header().Get(ratelimit.RetryAfterHeader))
+}
@@ -0,0 +1,162 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/webhook"
+)
+
+type webhookHandler struct {
+	webhookService webhookService
+	encoder        encoder
+	// authorize returns a middleware requiring the given scopes.
+	authorize func(scopes ...string) func(http.Handler) http.Handler
+	// limit rate limits requests by their route.
+	limit func(http.Handler) http.Handler
+}
+
+func (h webhookHandler) GetRoutes() func(r chi.Router) {
+	var (
+		read  = h.authorize(example.ScopeRead)
+		write = h.authorize(example.ScopeWrite)
+	)
+
+	return func(r chi.Router) {
+		r = r.With(h.limit)
+
+		r.With(write).Post("/", h.createSubscription)
+		r.With(read).Get("/", h.listSubscriptions)
+		r.With(read).Get("/{id}", h.getSubscription)
+		r.With(write).Delete("/{id}", h.deleteSubscription)
+		r.With(read).Get("/{id}/deliveries", h.listDeliveries)
+		r.With(read).Get("/{id}/deliveries/{deliveryID}/attempts", h.listAttempts)
+	}
+}
+
+func (h *webhookHandler) createSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	var dto webhook.SubscriptionDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("body", "could not be decoded: %v", err))
+		return
+	}
+
+	res, err := h.webhookService.CreateSubscription(ctx, dto)
+	if err != nil {
+		h.encoder.error(ctx, w, err)
+		return
+	}
+
+	h.encoder.respond(ctx, w, res, http.StatusCreated)
+}
+
+func (h *webhookHandler) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	res, err := h.webhookService.ListSubscriptions(ctx)
+	if err != nil {
+		h.encoder.error(ctx, w, err)
+		return
+	}
+
+	h.encoder.respond(ctx, w, res, http.StatusOK)
+}
+
+func (h *webhookHandler) getSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("id", "must be a UUID"))
+		return
+	}
+
+	res, err := h.webhookService.GetSubscription(ctx, id)
+	if err != nil {
+		h.encoder.error(ctx, w, err)
+		return
+	}
+
+	h.encoder.respond(ctx, w, res, http.StatusOK)
+}
+
+func (h *webhookHandler) deleteSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("id", "must be a UUID"))
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(ctx, id); err != nil {
+		h.encoder.error(ctx, w, err)
+		return
+	}
+
+	h.encoder.respond(ctx, w, nil, http.StatusNoContent)
+}
+
+func (h *webhookHandler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("id", "must be a UUID"))
+		return
+	}
+
+	var limit int
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil {
+			h.encoder.error(ctx, w, example.InvalidField("limit", "must be an integer"))
+			return
+		}
+	}
+
+	res, err := h.webhookService.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		h.encoder.error(ctx, w, err)
+		return
+	}
+
+	h.encoder.respond(ctx, w, res, http.StatusOK)
+}
+
+func (h *webhookHandler) listAttempts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("id", "must be a UUID"))
+		return
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		h.encoder.error(ctx, w, example.InvalidField("deliveryID", "must be a UUID"))
+		return
+	}
+
+	res, err := h.webhookService.ListAttempts(ctx, id, deliveryID)
+	if err != nil {
+		h.encoder.error(ctx, w, err)
+		return
+	}
+
+	h.encoder.respond(ctx, w, res, http.StatusOK)
+}
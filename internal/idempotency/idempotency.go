@@ -0,0 +1,242 @@
+// Package idempotency makes retrying unsafe requests safe: the response to
+// the first request carrying an Idempotency-Key is stored and replayed to
+// retries of the same request instead of handling them again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+const (
+	// Header carries the client chosen key of a request.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on replayed responses.
+	ReplayedHeader = "Idempotent-Replayed"
+	// MaxKeyLength is the maximum length of a key.
+	MaxKeyLength = 255
+	// DefaultTTL is how long responses are replayed when no TTL is given.
+	DefaultTTL = 24 * time.Hour
+	// DefaultLockTimeout is how long a key stays reserved by a request in
+	// progress when no lock timeout is given.
+	DefaultLockTimeout = time.Minute
+)
+
+var (
+	// ErrInvalidKey is returned for keys longer than MaxKeyLength.
+	ErrInvalidKey = errors.New("invalid idempotency key")
+	// ErrInProgress is returned while the first request of a key is
+	// handled.
+	ErrInProgress = errors.New("request with idempotency key in progress")
+	// ErrMismatch is returned when a key is reused for a different request.
+	ErrMismatch = errors.New("idempotency key reused with a different request")
+)
+
+// Record is the stored state of a key. Keys are scoped to the principal
+// sending them so clients can't replay each other's responses.
+type Record struct {
+	Scope       string // ID of the principal, empty if unauthenticated.
+	Key         string
+	Fingerprint string    // Hash of the method, path and body of the request.
+	Response    *Response // Nil while the first request is in progress.
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	// LockedUntil is when a record without response may be taken over, as
+	// its request presumably died without releasing it.
+	LockedUntil time.Time
+}
+
+// Response is a captured response.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Store stores records.
+type Store interface {
+	// Reserve stores r unless an unexpired record with the same scope and
+	// key exists that has a response or is still locked, in which case that
+	// record is returned and reserved is false.
+	Reserve(ctx context.Context, r Record) (existing Record, reserved bool, err error)
+	// Complete stores the response of the reserved record r, unless it was
+	// taken over since.
+	Complete(ctx context.Context, r Record, resp Response) error
+	// Release deletes the reserved record r if it has no response and was
+	// not taken over since, so the request can be retried.
+	Release(ctx context.Context, r Record) error
+	// DeleteExpired deletes the records expired at now and returns their
+	// number.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ErrorHandler writes the response of a failed request.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware replays the stored response of requests with a known
+// Idempotency-Key for ttl. Requests without the header are passed through.
+// Responses with a 5xx status are not stored, as retrying them may succeed.
+// A key stays reserved by its request in progress for lockTimeout, which
+// should exceed the duration of requests, after which a retry takes it
+// over. On failure onError is called with an error wrapping ErrInvalidKey,
+// ErrInProgress, ErrMismatch or an error of store.
+//
+// It must run after authentication, as keys are scoped to the principal.
+func Middleware(store Store, ttl, lockTimeout time.Duration, logger *logging.Logger, onError ErrorHandler) func(http.Handler) http.Handler {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	if lockTimeout <= 0 {
+		lockTimeout = DefaultLockTimeout
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			key := r.Header.Get(Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > MaxKeyLength {
+				onError(w, r, fmt.Errorf("key is longer than %d characters: %w", MaxKeyLength, ErrInvalidKey))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				onError(w, r, fmt.Errorf("could not read request body: %w", err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			var scope string
+			if p, ok := auth.FromContext(ctx); ok {
+				scope = p.ID()
+			}
+
+			now := time.Now().UTC()
+			record := Record{
+				Scope:       scope,
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(ttl),
+				LockedUntil: now.Add(lockTimeout),
+			}
+
+			existing, reserved, err := store.Reserve(ctx, record)
+			if err != nil {
+				onError(w, r, fmt.Errorf("could not reserve idempotency key: %w", err))
+				return
+			}
+
+			if !reserved {
+				switch {
+				case existing.Fingerprint != record.Fingerprint:
+					onError(w, r, ErrMismatch)
+				case existing.Response == nil:
+					onError(w, r, ErrInProgress)
+				default:
+					replay(w, *existing.Response)
+				}
+
+				return
+			}
+
+			// The request context may be canceled once the response is
+			// written, the record must be settled regardless.
+			settleCtx := context.Background()
+
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+
+				if err := store.Release(settleCtx, record); err != nil {
+					logger.ErrorCtx(r.Context(), fmt.Errorf("could not release idempotency key: %w", err))
+				}
+			}()
+
+			var buf bytes.Buffer
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			if status >= http.StatusInternalServerError {
+				return
+			}
+
+			resp := Response{
+				StatusCode: status,
+				Header:     storedHeader(w.Header()),
+				Body:       buf.Bytes(),
+			}
+
+			if err := store.Complete(settleCtx, record, resp); err != nil {
+				logger.ErrorCtx(r.Context(), fmt.Errorf("could not store idempotent response: %w", err))
+				return
+			}
+
+			completed = true
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// fingerprint identifies a request by its method, path and body.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.Path)
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// storedHeader returns a copy of h without the headers describing the
+// state of the client rather than the response, such as its remaining rate
+// limit, which a replay must not overwrite with stale values.
+func storedHeader(h http.Header) http.Header {
+	stored := h.Clone()
+	for k := range stored {
+		if strings.HasPrefix(k, "Ratelimit-") || k == "Retry-After" {
+			delete(stored, k)
+		}
+	}
+
+	return stored
+}
+
+func replay(w http.ResponseWriter, resp Response) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(ReplayedHeader, "true")
+
+	w.WriteHeader(resp.StatusCode)
+	w.Write(resp.Body)
+}
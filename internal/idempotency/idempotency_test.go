@@ -0,0 +1,370 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+// fakeStore keeps records in memory.
+type fakeStore struct {
+	mu      sync.Mutex
+	records map[[2]string]Record
+}
+
+func (s *fakeStore) Reserve(ctx context.Context, r Record) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.records == nil {
+		s.records = map[[2]string]Record{}
+	}
+
+	existing, ok := s.records[[2]string{r.Scope, r.Key}]
+	if ok && existing.ExpiresAt.After(r.CreatedAt) && (existing.Response != nil || existing.LockedUntil.After(r.CreatedAt)) {
+		return existing, false, nil
+	}
+
+	s.records[[2]string{r.Scope, r.Key}] = r
+	return r, true, nil
+}
+
+func (s *fakeStore) Complete(ctx context.Context, r Record, resp Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.records[[2]string{r.Scope, r.Key}]
+	if ok && existing.CreatedAt.Equal(r.CreatedAt) && existing.Response == nil {
+		existing.Response = &resp
+		s.records[[2]string{r.Scope, r.Key}] = existing
+	}
+	return nil
+}
+
+func (s *fakeStore) Release(ctx context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.records[[2]string{r.Scope, r.Key}]
+	if ok && existing.CreatedAt.Equal(r.CreatedAt) && existing.Response == nil {
+		delete(s.records, [2]string{r.Scope, r.Key})
+	}
+	return nil
+}
+
+func (s *fakeStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	type request struct {
+		key    string
+		body   string
+		status int // Status the handler responds with.
+	}
+
+	tests := []struct {
+		name             string
+		requests         []request
+		expectedCalls    int
+		expectedStatus   int // Of the last request.
+		expectedErr      error
+		expectedReplayed bool
+	}{
+		{
+			name: "without key",
+			requests: []request{
+				{body: `{"name":"a"}`, status: http.StatusOK},
+				{body: `{"name":"a"}`, status: http.StatusOK},
+			},
+			expectedCalls:  2,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "retry replayed",
+			requests: []request{
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusOK},
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusOK},
+			},
+			expectedCalls:    1,
+			expectedStatus:   http.StatusOK,
+			expectedReplayed: true,
+		},
+		{
+			name: "client error replayed",
+			requests: []request{
+				{key: "k1", body: `{}`, status: http.StatusBadRequest},
+				{key: "k1", body: `{}`, status: http.StatusOK},
+			},
+			expectedCalls:    1,
+			expectedStatus:   http.StatusBadRequest,
+			expectedReplayed: true,
+		},
+		{
+			name: "server error not stored",
+			requests: []request{
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusServiceUnavailable},
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusOK},
+			},
+			expectedCalls:  2,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "key reused with other body",
+			requests: []request{
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusOK},
+				{key: "k1", body: `{"name":"b"}`, status: http.StatusOK},
+			},
+			expectedCalls: 1,
+			expectedErr:   ErrMismatch,
+		},
+		{
+			name: "other keys handled",
+			requests: []request{
+				{key: "k1", body: `{"name":"a"}`, status: http.StatusOK},
+				{key: "k2", body: `{"name":"a"}`, status: http.StatusOK},
+			},
+			expectedCalls:  2,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "key too long",
+			requests: []request{
+				{key: strings.Repeat("k", MaxKeyLength+1), body: `{}`, status: http.StatusOK},
+			},
+			expectedErr: ErrInvalidKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var (
+				calls  int
+				status int
+				gotErr error
+			)
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				body, _ := io.ReadAll(r.Body)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				w.Write(body)
+			})
+
+			onError := func(w http.ResponseWriter, r *http.Request, err error) {
+				gotErr = err
+				w.WriteHeader(http.StatusTeapot)
+			}
+
+			handler := Middleware(&fakeStore{}, time.Hour, time.Minute, logging.New(io.Discard, logging.Config{}), onError)(next)
+
+			// Act
+			var res *httptest.ResponseRecorder
+			for _, req := range tt.requests {
+				status = req.status
+				gotErr = nil
+
+				r := httptest.NewRequest(http.MethodPost, "/api/example", strings.NewReader(req.body))
+				if req.key != "" {
+					r.Header.Set(Header, req.key)
+				}
+
+				res = httptest.NewRecorder()
+				handler.ServeHTTP(res, r)
+			}
+
+			// Assert
+			assert.Equal(t, tt.expectedCalls, calls)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, gotErr, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, gotErr)
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.Equal(t, tt.requests[len(tt.requests)-1].body, res.Body.String())
+			assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+			if tt.expectedReplayed {
+				assert.Equal(t, "true", res.Header().Get(ReplayedHeader))
+			} else {
+				assert.Empty(t, res.Header().Get(ReplayedHeader))
+			}
+		})
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	// Arrange
+	var (
+		store   = &fakeStore{}
+		started = make(chan struct{})
+		finish  = make(chan struct{})
+		gotErr  error
+	)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+	}
+
+	handler := Middleware(store, time.Hour, time.Minute, logging.New(io.Discard, logging.Config{}), onError)(next)
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/example", strings.NewReader(`{}`))
+		r.Header.Set(Header, "k1")
+		return r
+	}
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+		close(done)
+	}()
+	<-started
+
+	// Act
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+	close(finish)
+	<-done
+
+	// Assert
+	assert.ErrorIs(t, gotErr, ErrInProgress)
+}
+
+func TestMiddlewareScopedToPrincipal(t *testing.T) {
+	// Arrange
+	var calls int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	handler := Middleware(&fakeStore{}, time.Hour, time.Minute, logging.New(io.Discard, logging.Config{}), onError)(next)
+
+	principals := []auth.Principal{
+		{Subject: "alice", Method: auth.MethodBasic},
+		{Subject: "bob", Method: auth.MethodBasic},
+		{Subject: "alice", Method: auth.MethodJWT},
+	}
+
+	// Act
+	for _, p := range principals {
+		r := httptest.NewRequest(http.MethodPost, "/api/example", strings.NewReader(`{}`))
+		r.Header.Set(Header, "k1")
+		r = r.WithContext(auth.WithPrincipal(r.Context(), p))
+
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	// Assert
+	assert.Equal(t, len(principals), calls, "principals should not share keys, even with the same subject")
+}
+
+func TestMiddlewareTakesOverStaleReservation(t *testing.T) {
+	// Arrange
+	now := time.Now().UTC()
+	store := &fakeStore{records: map[[2]string]Record{
+		{"", "k1"}: {
+			Key:         "k1",
+			CreatedAt:   now.Add(-2 * time.Minute),
+			ExpiresAt:   now.Add(time.Hour),
+			LockedUntil: now.Add(-time.Minute),
+		},
+	}}
+
+	var calls int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	handler := Middleware(store, time.Hour, time.Minute, logging.New(io.Discard, logging.Config{}), onError)(next)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/example", strings.NewReader(`{}`))
+	r.Header.Set(Header, "k1")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, 1, calls, "a reservation whose lock passed should be taken over")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	require.NotNil(t, store.records[[2]string{"", "k1"}].Response)
+}
+
+func TestMiddlewareReplaysFreshRateLimitHeaders(t *testing.T) {
+	// Arrange
+	store := &fakeStore{}
+
+	// Stands in for the rate limiter, which sets its headers before the
+	// idempotency middleware runs.
+	remaining := 10
+	limit := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remaining--
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/api/example/1")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	handler := limit(Middleware(store, time.Hour, time.Minute, logging.New(io.Discard, logging.Config{}), onError)(next))
+
+	send := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/example", strings.NewReader(`{}`))
+		r.Header.Set(Header, "k1")
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// Act
+	send()
+	replayed := send()
+
+	// Assert
+	assert.Equal(t, "true", replayed.Header().Get(ReplayedHeader))
+	assert.Equal(t, "/api/example/1", replayed.Header().Get("Location"))
+	assert.Equal(t, "8", replayed.Header().Get("RateLimit-Remaining"), "replay should keep the current rate limit")
+	assert.Empty(t, store.records[[2]string{"", "k1"}].Response.Header.Get("RateLimit-Remaining"))
+}
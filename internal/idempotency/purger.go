@@ -0,0 +1,51 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+// DefaultPurgeInterval is the interval of a Purger when none is given.
+const DefaultPurgeInterval = time.Hour
+
+// Purger periodically deletes expired records. Expired records are ignored
+// by the store until then, purging only keeps the store from growing.
+type Purger struct {
+	Store    Store
+	Interval time.Duration
+	Logger   *logging.Logger
+}
+
+// Run purges expired records until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := p.Store.DeleteExpired(ctx, time.Now().UTC())
+		if err != nil {
+			if ctx.Err() == nil {
+				p.Logger.Error(fmt.Errorf("could not purge idempotency keys: %w", err))
+			}
+			continue
+		}
+
+		if n > 0 {
+			p.Logger.InfoWith("purged expired idempotency keys", "count", n)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	loggerKey struct{}
+	fieldsKey struct{}
+)
+
+// nop discards all entries, it is the logger of contexts without one.
+var nop = &Logger{l: zap.NewNop(), dropped: &Dropped{}}
+
+// WithContext returns a copy of ctx carrying l, see FromContext.
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger of ctx, or a logger discarding all entries
+// if ctx carries none. Log with its Ctx methods to include the fields of ctx.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+		return l
+	}
+
+	return nop
+}
+
+// WithFields returns a copy of ctx with fields added to the fields logged by
+// the Ctx methods, so they accumulate along the call chain.
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	existing, _ := ctx.Value(fieldsKey{}).([]Field)
+
+	// Copied, so contexts derived from the same parent don't share fields.
+	all := make([]Field, 0, len(existing)+len(fields))
+	all = append(all, existing...)
+	all = append(all, fields...)
+
+	return context.WithValue(ctx, fieldsKey{}, all)
+}
+
+// ContextFields returns the fields added to ctx by WithFields and the trace
+// and span ID of the span of ctx, if any.
+func ContextFields(ctx context.Context) []Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	// Appending to the result must not write to the fields of ctx.
+	fields = fields[:len(fields):len(fields)]
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return fields
+	}
+
+	return append(fields,
+		String("traceID", sc.TraceID().String()),
+		String("spanID", sc.SpanID().String()),
+	)
+}
+
+// DebugCtx logs msg with the fields of ctx, see ContextFields.
+func (l *Logger) DebugCtx(ctx context.Context, msg string, fields ...Field) {
+	l.l.Debug(msg, append(ContextFields(ctx), fields...)...)
+}
+
+// InfoCtx logs msg with the fields of ctx, see ContextFields.
+func (l *Logger) InfoCtx(ctx context.Context, msg string, fields ...Field) {
+	l.l.Info(msg, append(ContextFields(ctx), fields...)...)
+}
+
+// WarnCtx logs msg with the fields of ctx, see ContextFields.
+func (l *Logger) WarnCtx(ctx context.Context, msg string, fields ...Field) {
+	l.l.Warn(msg, append(ContextFields(ctx), fields...)...)
+}
+
+// ErrorCtx logs err with the fields of ctx, see ContextFields.
+func (l *Logger) ErrorCtx(ctx context.Context, err error, fields ...Field) {
+	l.l.Error(err.Error(), append(ContextFields(ctx), fields...)...)
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestFromContext(t *testing.T) {
+	// Arrange.
+	var buf bytes.Buffer
+	l := New(&buf, Config{Level: InfoLevel})
+
+	// Act.
+	FromContext(context.Background()).Info("discarded")
+	FromContext(WithContext(context.Background(), l)).Info("logged")
+
+	// Assert.
+	assert.Equal(t, "{\"level\":\"info\",\"msg\":\"logged\"}\n", buf.String())
+}
+
+func TestCtxMethods(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		log      func(l *Logger, ctx context.Context)
+		expected string
+	}{
+		{
+			name:     "info without context fields",
+			ctx:      context.Background(),
+			log:      func(l *Logger, ctx context.Context) { l.InfoCtx(ctx, "test message", String("key", "val")) },
+			expected: `{"level":"info","msg":"test message","key":"val"}`,
+		},
+		{
+			name: "info with accumulated fields",
+			ctx: WithFields(
+				WithFields(context.Background(), String("requestID", "req-1")),
+				String("principal", "alice"),
+			),
+			log:      func(l *Logger, ctx context.Context) { l.InfoCtx(ctx, "test message", String("key", "val")) },
+			expected: `{"level":"info","msg":"test message","requestID":"req-1","principal":"alice","key":"val"}`,
+		},
+		{
+			name:     "error with trace",
+			ctx:      WithFields(traced, String("requestID", "req-1")),
+			log:      func(l *Logger, ctx context.Context) { l.ErrorCtx(ctx, fmt.Errorf("some-error")) },
+			expected: `{"level":"error","msg":"some-error","requestID":"req-1","traceID":"4bf92f3577b34da6a3ce929d0e0e4736","spanID":"00f067aa0ba902b7"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange.
+			var buf bytes.Buffer
+			l := New(&buf, Config{Level: InfoLevel})
+
+			// Act.
+			tc.log(l, tc.ctx)
+
+			// Assert.
+			assert.JSONEq(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestWithFields_DoesNotShareFields(t *testing.T) {
+	// Arrange.
+	parent := WithFields(context.Background(), String("a", "1"))
+
+	// Act.
+	first := WithFields(parent, String("b", "2"))
+	second := WithFields(parent, String("c", "3"))
+
+	// Assert.
+	assert.Equal(t, []Field{String("a", "1")}, ContextFields(parent))
+	assert.Equal(t, []Field{String("a", "1"), String("b", "2")}, ContextFields(first))
+	assert.Equal(t, []Field{String("a", "1"), String("c", "3")}, ContextFields(second))
+}
@@ -0,0 +1,272 @@
+package logging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Encoding is the format of logged entries.
+type Encoding string
+
+const (
+	EncodingJSON    Encoding = "json"    // One JSON object per entry, the default.
+	EncodingConsole Encoding = "console" // Tab separated and human readable.
+	EncodingLogfmt  Encoding = "logfmt"  // key=value pairs.
+)
+
+// newEncoder returns an encoder of encoding, JSON if empty or unknown.
+// Levels of the console encoding are colored if color is set.
+func newEncoder(encoding Encoding, color bool, cfg zapcore.EncoderConfig) zapcore.Encoder {
+	switch encoding {
+	case EncodingConsole:
+		cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		if color {
+			cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+
+		return zapcore.NewConsoleEncoder(cfg)
+	case EncodingLogfmt:
+		return &logfmtEncoder{cfg: cfg, buf: bufferPool.Get()}
+	default:
+		return zapcore.NewJSONEncoder(cfg)
+	}
+}
+
+var bufferPool = buffer.NewPool()
+
+// logfmtEncoder encodes entries as space separated key=value pairs. Keys of
+// nested objects and namespaces are prefixed by their parents, as in
+// "user.id=1", and arrays are encoded as JSON values.
+type logfmtEncoder struct {
+	cfg    zapcore.EncoderConfig
+	buf    *buffer.Buffer // Of the pairs of the encoder, fields of With.
+	prefix string         // Of the keys, see OpenNamespace.
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{cfg: e.cfg, buf: bufferPool.Get(), prefix: e.prefix}
+	clone.buf.Write(e.buf.Bytes())
+
+	return clone
+}
+
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := &logfmtEncoder{cfg: e.cfg, buf: bufferPool.Get()}
+
+	if e.cfg.TimeKey != "" && e.cfg.EncodeTime != nil {
+		line.addPrimitive(e.cfg.TimeKey, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeTime(ent.Time, enc) })
+	}
+	if e.cfg.LevelKey != "" && e.cfg.EncodeLevel != nil {
+		line.addPrimitive(e.cfg.LevelKey, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeLevel(ent.Level, enc) })
+	}
+	if e.cfg.NameKey != "" && ent.LoggerName != "" {
+		line.AddString(e.cfg.NameKey, ent.LoggerName)
+	}
+	if e.cfg.CallerKey != "" && ent.Caller.Defined && e.cfg.EncodeCaller != nil {
+		line.addPrimitive(e.cfg.CallerKey, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeCaller(ent.Caller, enc) })
+	}
+	if e.cfg.MessageKey != "" {
+		line.AddString(e.cfg.MessageKey, ent.Message)
+	}
+
+	if e.buf.Len() > 0 {
+		line.separate()
+		line.buf.Write(e.buf.Bytes())
+	}
+
+	// Fields are in the namespace of the encoder.
+	line.prefix = e.prefix
+	for _, f := range fields {
+		f.AddTo(line)
+	}
+	line.prefix = ""
+
+	if e.cfg.StacktraceKey != "" && ent.Stack != "" {
+		line.AddString(e.cfg.StacktraceKey, ent.Stack)
+	}
+
+	if e.cfg.LineEnding != "" {
+		line.buf.AppendString(e.cfg.LineEnding)
+	} else {
+		line.buf.AppendString(zapcore.DefaultLineEnding)
+	}
+
+	return line.buf, nil
+}
+
+func (e *logfmtEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	// The map encoder collects the elements, including nested ones.
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddArray(key, arr); err != nil {
+		return err
+	}
+
+	return e.addJSON(key, m.Fields[key])
+}
+
+func (e *logfmtEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	nested := &logfmtEncoder{cfg: e.cfg, buf: e.buf, prefix: e.prefix + key + "."}
+	return obj.MarshalLogObject(nested)
+}
+
+func (e *logfmtEncoder) AddBinary(key string, value []byte) {
+	e.AddString(key, base64.StdEncoding.EncodeToString(value))
+}
+
+func (e *logfmtEncoder) AddByteString(key string, value []byte) {
+	e.AddString(key, string(value))
+}
+
+func (e *logfmtEncoder) AddBool(key string, value bool) {
+	e.addValue(key, strconv.FormatBool(value))
+}
+
+func (e *logfmtEncoder) AddComplex128(key string, value complex128) {
+	e.addValue(key, strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+func (e *logfmtEncoder) AddComplex64(key string, value complex64) {
+	e.addValue(key, strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+func (e *logfmtEncoder) AddDuration(key string, value time.Duration) {
+	if e.cfg.EncodeDuration == nil {
+		e.AddInt64(key, int64(value))
+		return
+	}
+
+	e.addPrimitive(key, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeDuration(value, enc) })
+}
+
+func (e *logfmtEncoder) AddFloat64(key string, value float64) {
+	e.addValue(key, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (e *logfmtEncoder) AddFloat32(key string, value float32) {
+	e.addValue(key, strconv.FormatFloat(float64(value), 'g', -1, 32))
+}
+
+func (e *logfmtEncoder) AddInt(key string, value int)     { e.AddInt64(key, int64(value)) }
+func (e *logfmtEncoder) AddInt32(key string, value int32) { e.AddInt64(key, int64(value)) }
+func (e *logfmtEncoder) AddInt16(key string, value int16) { e.AddInt64(key, int64(value)) }
+func (e *logfmtEncoder) AddInt8(key string, value int8)   { e.AddInt64(key, int64(value)) }
+
+func (e *logfmtEncoder) AddInt64(key string, value int64) {
+	e.addValue(key, strconv.FormatInt(value, 10))
+}
+
+func (e *logfmtEncoder) AddString(key, value string) {
+	e.addValue(key, value)
+}
+
+func (e *logfmtEncoder) AddTime(key string, value time.Time) {
+	if e.cfg.EncodeTime == nil {
+		e.AddInt64(key, value.UnixNano())
+		return
+	}
+
+	e.addPrimitive(key, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeTime(value, enc) })
+}
+
+func (e *logfmtEncoder) AddUint(key string, value uint)       { e.AddUint64(key, uint64(value)) }
+func (e *logfmtEncoder) AddUint32(key string, value uint32)   { e.AddUint64(key, uint64(value)) }
+func (e *logfmtEncoder) AddUint16(key string, value uint16)   { e.AddUint64(key, uint64(value)) }
+func (e *logfmtEncoder) AddUint8(key string, value uint8)     { e.AddUint64(key, uint64(value)) }
+func (e *logfmtEncoder) AddUintptr(key string, value uintptr) { e.AddUint64(key, uint64(value)) }
+
+func (e *logfmtEncoder) AddUint64(key string, value uint64) {
+	e.addValue(key, strconv.FormatUint(value, 10))
+}
+
+func (e *logfmtEncoder) AddReflected(key string, value interface{}) error {
+	return e.addJSON(key, value)
+}
+
+func (e *logfmtEncoder) OpenNamespace(key string) {
+	e.prefix += key + "."
+}
+
+// addPrimitive adds the value encoded by encode, such as the time encoded
+// by the configured time encoder.
+func (e *logfmtEncoder) addPrimitive(key string, encode func(zapcore.PrimitiveArrayEncoder)) {
+	m := zapcore.NewMapObjectEncoder()
+	_ = m.AddArray(key, zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		encode(enc)
+		return nil
+	}))
+
+	values, _ := m.Fields[key].([]interface{})
+	switch len(values) {
+	case 0:
+		e.addValue(key, "")
+	case 1:
+		e.addValue(key, fmt.Sprint(values[0]))
+	default:
+		_ = e.addJSON(key, values)
+	}
+}
+
+func (e *logfmtEncoder) addJSON(key string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	e.addValue(key, string(b))
+	return nil
+}
+
+func (e *logfmtEncoder) addValue(key, value string) {
+	e.separate()
+	e.buf.AppendString(logfmtKey(e.prefix + key))
+	e.buf.AppendByte('=')
+
+	if needsQuoting(value) {
+		e.buf.AppendString(strconv.Quote(value))
+	} else {
+		e.buf.AppendString(value)
+	}
+}
+
+func (e *logfmtEncoder) separate() {
+	if e.buf.Len() > 0 {
+		e.buf.AppendByte(' ')
+	}
+}
+
+// logfmtKey replaces the characters of key that would end it.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func needsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func (u user) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("id", u.ID)
+	enc.AddString("name", u.Name)
+	return nil
+}
+
+func TestLogfmtEncoding(t *testing.T) {
+	// Arrange.
+	testCases := []struct {
+		name     string
+		log      func(l *Logger)
+		expected string
+	}{
+		{
+			name: "plain values",
+			log: func(l *Logger) {
+				l.Info("started", Int("port", 8080), Bool("tls", false), Duration("timeout", 1500*time.Millisecond))
+			},
+			expected: "level=info msg=started port=8080 tls=false timeout=1.5\n",
+		},
+		{
+			name: "quoted values",
+			log: func(l *Logger) {
+				l.Info("request failed", String("path", "/a b"), String("q", `x="1"`), String("empty", ""))
+			},
+			expected: `level=info msg="request failed" path="/a b" q="x=\"1\"" empty=""` + "\n",
+		},
+		{
+			name:     "error",
+			log:      func(l *Logger) { l.Error(errors.New("boom"), Err(errors.New("cause"))) },
+			expected: "level=error msg=boom error=cause\n",
+		},
+		{
+			name:     "nested object",
+			log:      func(l *Logger) { l.Info("created", Object("user", user{ID: 1, Name: "Ada Lovelace"})) },
+			expected: `level=info msg=created user.id=1 user.name="Ada Lovelace"` + "\n",
+		},
+		{
+			name:     "array",
+			log:      func(l *Logger) { l.Info("tagged", Strings("tags", []string{"a", "b"})) },
+			expected: `level=info msg=tagged tags="[\"a\",\"b\"]"` + "\n",
+		},
+		{
+			name: "context fields and namespace",
+			log: func(l *Logger) {
+				child := l.Named("http").With(String("requestID", "r1"), Namespace("req"))
+				child.Info("served", Int("status", 200))
+			},
+			expected: "level=info logger=http msg=served requestID=r1 req.status=200\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, Config{Level: InfoLevel, Encoding: EncodingLogfmt})
+
+			// Act.
+			tc.log(l)
+
+			// Assert.
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestConsoleEncoding(t *testing.T) {
+	// Arrange.
+	testCases := []struct {
+		name     string
+		color    bool
+		expected string
+	}{
+		{
+			name:     "plain",
+			expected: "INFO\tstarted\t{\"port\": 8080}\n",
+		},
+		{
+			name:     "colored",
+			color:    true,
+			expected: "\x1b[34mINFO\x1b[0m\tstarted\t{\"port\": 8080}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, Config{Level: InfoLevel, Encoding: EncodingConsole, Color: tc.color})
+
+			// Act.
+			l.Info("started", Int("port", 8080))
+
+			// Assert.
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestSinks(t *testing.T) {
+	// Arrange.
+	var (
+		stderr, file bytes.Buffer
+		warn         = WarnLevel
+	)
+
+	l := New(&stderr, Config{
+		Level: InfoLevel,
+		Sinks: []Sink{{Writer: &file, Encoding: EncodingLogfmt, Level: &warn}},
+	})
+
+	// Act.
+	l.Debug("not logged")
+	l.Info("info")
+	l.Warn("warn")
+
+	// Assert.
+	assert.Equal(t, "{\"level\":\"info\",\"msg\":\"info\"}\n{\"level\":\"warn\",\"msg\":\"warn\"}\n", stderr.String())
+	assert.Equal(t, "level=warn msg=warn\n", file.String())
+}
@@ -0,0 +1,238 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the format of the rotation time in the names of
+// rotated files, sorting in time order.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileConfig configures a log file rotated by size and time.
+type FileConfig struct {
+	Path        string
+	MaxSize     int64         // Bytes rotating the file, 0 for no limit.
+	RotateEvery time.Duration // Rotates the file at multiples of it since the epoch, 0 for never.
+	MaxAge      time.Duration // Rotated files older are deleted, 0 to keep them.
+	MaxBackups  int           // Rotated files kept, 0 for all.
+}
+
+// File is a log file rotated by size and time, to be used as the writer of
+// a Sink. Rotated files are kept next to it, named with the UTC time of
+// their rotation, such as app-2006-01-02T15-04-05.000.log for app.log.
+// Deleting rotated files past retention is best effort.
+type File struct {
+	conf FileConfig
+	now  func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	next time.Time // Of the next rotation by time, zero for none.
+}
+
+// OpenFile opens the log file of conf for appending, creating it and its
+// directory if needed.
+func OpenFile(conf FileConfig) (*File, error) {
+	f := &File{conf: conf, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *File) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.conf.Path), 0o755); err != nil {
+		return fmt.Errorf("could not create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.conf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("could not stat log file: %w", err)
+	}
+
+	// An existing file is due for rotation by the time it was last written.
+	since := f.now()
+	if info.Size() > 0 {
+		since = info.ModTime()
+	}
+
+	f.file, f.size, f.next = file, info.Size(), time.Time{}
+	if f.conf.RotateEvery > 0 {
+		f.next = since.Truncate(f.conf.RotateEvery).Add(f.conf.RotateEvery)
+	}
+
+	return nil
+}
+
+// Write writes p to the file, rotating it first if p would exceed the max
+// size or the rotation time has passed.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	bySize := f.conf.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.conf.MaxSize
+	byTime := !f.next.IsZero() && !f.now().Before(f.next)
+	if bySize || byTime {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Rotate rotates the file regardless of its size and age.
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+
+	return f.rotate()
+}
+
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("could not close log file: %w", err)
+	}
+	f.file = nil
+
+	// The file is reopened even if it could not be renamed, so logging goes
+	// on.
+	renameErr := os.Rename(f.conf.Path, f.backupName(f.now()))
+	if err := f.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return fmt.Errorf("could not rotate log file: %w", renameErr)
+	}
+
+	f.removeExpired()
+
+	return nil
+}
+
+// backupName returns the path of the file rotated at t.
+func (f *File) backupName(t time.Time) string {
+	prefix, ext := f.backupPattern()
+	return filepath.Join(filepath.Dir(f.conf.Path), prefix+t.UTC().Format(backupTimeFormat)+ext)
+}
+
+// backupPattern returns the prefix and extension of the names of rotated
+// files.
+func (f *File) backupPattern() (prefix, ext string) {
+	base := filepath.Base(f.conf.Path)
+	ext = filepath.Ext(base)
+
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// removeExpired deletes the rotated files beyond MaxBackups or older than
+// MaxAge.
+func (f *File) removeExpired() {
+	if f.conf.MaxBackups <= 0 && f.conf.MaxAge <= 0 {
+		return
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return
+	}
+
+	dir := filepath.Dir(f.conf.Path)
+	for i, b := range backups {
+		tooMany := f.conf.MaxBackups > 0 && i >= f.conf.MaxBackups
+		tooOld := f.conf.MaxAge > 0 && f.now().Sub(b.rotatedAt) > f.conf.MaxAge
+		if tooMany || tooOld {
+			_ = os.Remove(filepath.Join(dir, b.name))
+		}
+	}
+}
+
+type backup struct {
+	name      string
+	rotatedAt time.Time
+}
+
+// backups returns the rotated files, newest first.
+func (f *File) backups() ([]backup, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.conf.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, ext := f.backupPattern()
+
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backup{name: name, rotatedAt: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotatedAt.After(backups[j].rotatedAt) })
+
+	return backups, nil
+}
+
+// Sync commits the written entries to storage.
+func (f *File) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+
+	return f.file.Sync()
+}
+
+// Close closes the file, later writes fail.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	if err != nil && !errors.Is(err, os.ErrClosed) {
+		return fmt.Errorf("could not close log file: %w", err)
+	}
+
+	return nil
+}
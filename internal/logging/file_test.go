@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestFile opens app.log of a temporary directory at the time of now.
+func openTestFile(t *testing.T, conf FileConfig, now *time.Time) *File {
+	t.Helper()
+
+	conf.Path = filepath.Join(t.TempDir(), "app.log")
+	f := &File{conf: conf, now: func() time.Time { return *now }}
+	require.NoError(t, f.open())
+	t.Cleanup(func() { f.Close() })
+
+	return f
+}
+
+func dirFiles(t *testing.T, f *File) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(filepath.Dir(f.conf.Path))
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func readFile(t *testing.T, f *File, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join(filepath.Dir(f.conf.Path), name))
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+func TestFileRotatesBySize(t *testing.T) {
+	// Arrange.
+	now := time.Date(2022, 3, 1, 10, 30, 0, 0, time.UTC)
+	f := openTestFile(t, FileConfig{MaxSize: 10}, &now)
+
+	// Act.
+	_, err1 := f.Write([]byte("entry 1\n"))
+	_, err2 := f.Write([]byte("entry 2\n"))
+
+	// Assert.
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.Equal(t, []string{"app-2022-03-01T10-30-00.000.log", "app.log"}, dirFiles(t, f))
+	assert.Equal(t, "entry 1\n", readFile(t, f, "app-2022-03-01T10-30-00.000.log"))
+	assert.Equal(t, "entry 2\n", readFile(t, f, "app.log"))
+}
+
+func TestFileRotatesByTime(t *testing.T) {
+	// Arrange.
+	now := time.Date(2022, 3, 1, 10, 30, 0, 0, time.UTC)
+	f := openTestFile(t, FileConfig{RotateEvery: time.Hour}, &now)
+
+	_, err := f.Write([]byte("entry 1\n"))
+	require.NoError(t, err)
+
+	// Act.
+	now = now.Add(29 * time.Minute)
+	_, err1 := f.Write([]byte("entry 2\n"))
+	now = now.Add(time.Minute)
+	_, err2 := f.Write([]byte("entry 3\n"))
+
+	// Assert.
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.Equal(t, []string{"app-2022-03-01T11-00-00.000.log", "app.log"}, dirFiles(t, f))
+	assert.Equal(t, "entry 1\nentry 2\n", readFile(t, f, "app-2022-03-01T11-00-00.000.log"))
+	assert.Equal(t, "entry 3\n", readFile(t, f, "app.log"))
+}
+
+func TestFileRetention(t *testing.T) {
+	// Arrange.
+	testCases := []struct {
+		name     string
+		conf     FileConfig
+		expected []string
+	}{
+		{
+			name:     "keeps all",
+			conf:     FileConfig{},
+			expected: []string{"app-2022-03-01T00-00-00.000.log", "app-2022-03-02T00-00-00.000.log", "app-2022-03-03T00-00-00.000.log", "app-2022-03-04T00-00-00.000.log", "app.log"},
+		},
+		{
+			name:     "max backups",
+			conf:     FileConfig{MaxBackups: 2},
+			expected: []string{"app-2022-03-03T00-00-00.000.log", "app-2022-03-04T00-00-00.000.log", "app.log"},
+		},
+		{
+			name:     "max age",
+			conf:     FileConfig{MaxAge: 36 * time.Hour},
+			expected: []string{"app-2022-03-03T00-00-00.000.log", "app-2022-03-04T00-00-00.000.log", "app.log"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+			now := start
+			f := openTestFile(t, tc.conf, &now)
+
+			// Act.
+			for day := 0; day < 4; day++ {
+				now = start.AddDate(0, 0, day)
+				require.NoError(t, f.Rotate())
+			}
+
+			// Assert.
+			assert.Equal(t, tc.expected, dirFiles(t, f))
+		})
+	}
+}
+
+func TestFileClosed(t *testing.T) {
+	// Arrange.
+	now := time.Now()
+	f := openTestFile(t, FileConfig{}, &now)
+	require.NoError(t, f.Close())
+
+	// Act.
+	_, err := f.Write([]byte("entry\n"))
+
+	// Assert.
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.NoError(t, f.Close())
+}
@@ -0,0 +1,246 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrUnknownSubsystem is returned when changing the level of a subsystem
+// without a logger.
+var ErrUnknownSubsystem = errors.New("unknown subsystem")
+
+// Levels are the minimum levels of a logger and of its named subsystems,
+// see Logger.Named. They can be changed at runtime, optionally reverting
+// after a while. A subsystem follows the root level unless given its own.
+type Levels struct {
+	mu         sync.Mutex
+	root       *level
+	subsystems map[string]*level
+	now        func() time.Time
+}
+
+// level is the level of the root or a subsystem.
+type level struct {
+	atomic  zap.AtomicLevel
+	inherit atomic.Bool // Follow root instead of atomic.
+	root    *level      // nil for the root itself.
+	revert  *revert
+}
+
+// revert restores a level after a temporary change.
+type revert struct {
+	timer   *time.Timer
+	at      time.Time
+	level   Level
+	inherit bool
+}
+
+// LevelStatus describes the level of the root or a subsystem.
+type LevelStatus struct {
+	Subsystem string     `json:"subsystem,omitempty"` // Empty for the root.
+	Level     Level      `json:"level"`
+	Inherited bool       `json:"inherited,omitempty"` // Follows the root level.
+	RevertAt  *time.Time `json:"revertAt,omitempty"`
+}
+
+// NewLevels returns levels with the root level and overrides of the levels
+// of subsystems.
+func NewLevels(root Level, subsystems map[string]Level) *Levels {
+	ls := &Levels{
+		root:       &level{atomic: zap.NewAtomicLevelAt(root)},
+		subsystems: map[string]*level{},
+		now:        time.Now,
+	}
+
+	for name, l := range subsystems {
+		ls.subsystem(name).set(l, false)
+	}
+
+	return ls
+}
+
+// ParseSubsystemLevels parses subsystem=level pairs.
+func ParseSubsystemLevels(values []string) (map[string]Level, error) {
+	levels := map[string]Level{}
+	for _, v := range values {
+		name, text, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not a subsystem=level pair", v)
+		}
+
+		var l Level
+		if err := l.UnmarshalText([]byte(text)); err != nil {
+			return nil, fmt.Errorf("invalid level of %s: %w", name, err)
+		}
+
+		levels[name] = l
+	}
+
+	return levels, nil
+}
+
+// subsystem returns the level of the subsystem name, following the root
+// level if new.
+func (ls *Levels) subsystem(name string) *level {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	l, ok := ls.subsystems[name]
+	if !ok {
+		l = &level{atomic: zap.NewAtomicLevel(), root: ls.root}
+		l.inherit.Store(true)
+		ls.subsystems[name] = l
+	}
+
+	return l
+}
+
+// lookup returns the level of the subsystem name, the root if empty.
+func (ls *Levels) lookup(name string) (*level, error) {
+	if name == "" {
+		return ls.root, nil
+	}
+
+	l, ok := ls.subsystems[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownSubsystem, name)
+	}
+
+	return l, nil
+}
+
+// SetLevel sets the level of the subsystem name, the root level if empty.
+// A positive revertAfter restores the level the subsystem had before any
+// pending temporary change once it expires.
+func (ls *Levels) SetLevel(name string, lvl Level, revertAfter time.Duration) (LevelStatus, error) {
+	return ls.change(name, lvl, false, revertAfter)
+}
+
+// Inherit makes the subsystem name follow the root level again, with the
+// same revertAfter semantics as SetLevel.
+func (ls *Levels) Inherit(name string, revertAfter time.Duration) (LevelStatus, error) {
+	if name == "" {
+		return LevelStatus{}, errors.New("the root level cannot be inherited")
+	}
+
+	return ls.change(name, 0, true, revertAfter)
+}
+
+func (ls *Levels) change(name string, lvl Level, inherit bool, revertAfter time.Duration) (LevelStatus, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	l, err := ls.lookup(name)
+	if err != nil {
+		return LevelStatus{}, err
+	}
+
+	// A pending revert restores the level from before the first of the
+	// temporary changes.
+	prevLevel, prevInherit := l.atomic.Level(), l.inherit.Load()
+	if l.revert != nil {
+		l.revert.timer.Stop()
+		prevLevel, prevInherit = l.revert.level, l.revert.inherit
+		l.revert = nil
+	}
+
+	l.set(lvl, inherit)
+
+	if revertAfter > 0 {
+		r := &revert{at: ls.now().Add(revertAfter), level: prevLevel, inherit: prevInherit}
+		r.timer = time.AfterFunc(revertAfter, func() {
+			ls.mu.Lock()
+			defer ls.mu.Unlock()
+
+			// Replaced by a later change.
+			if l.revert != r {
+				return
+			}
+
+			l.set(r.level, r.inherit)
+			l.revert = nil
+		})
+		l.revert = r
+	}
+
+	return l.status(name), nil
+}
+
+// Status returns the status of the root level followed by those of the
+// subsystems ordered by name.
+func (ls *Levels) Status() []LevelStatus {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	names := make([]string, 0, len(ls.subsystems))
+	for name := range ls.subsystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	statuses := []LevelStatus{ls.root.status("")}
+	for _, name := range names {
+		statuses = append(statuses, ls.subsystems[name].status(name))
+	}
+
+	return statuses
+}
+
+func (l *level) set(lvl Level, inherit bool) {
+	l.atomic.SetLevel(lvl)
+	l.inherit.Store(inherit && l.root != nil)
+}
+
+func (l *level) Enabled(lvl Level) bool {
+	if l.inherit.Load() {
+		return l.root.Enabled(lvl)
+	}
+
+	return l.atomic.Enabled(lvl)
+}
+
+func (l *level) status(name string) LevelStatus {
+	s := LevelStatus{Subsystem: name, Level: l.atomic.Level(), Inherited: l.inherit.Load()}
+	if s.Inherited {
+		s.Level = l.root.atomic.Level()
+	}
+
+	if l.revert != nil {
+		at := l.revert.at
+		s.RevertAt = &at
+	}
+
+	return s
+}
+
+// levelCore filters the entries of a core by a level that may change at
+// runtime. The core must enable all levels the level may be set to.
+type levelCore struct {
+	zapcore.Core
+	level *level
+}
+
+func (c levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl)
+}
+
+func (c levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c levelCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(e.Level) {
+		return ce
+	}
+
+	// The wrapped core checks the levels of its sinks.
+	return c.Core.Check(e, ce)
+}
@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamed(t *testing.T) {
+	// Arrange.
+	var buf bytes.Buffer
+	l := New(&buf, Config{Level: InfoLevel, Levels: map[string]Level{"http": ErrorLevel}})
+
+	http := l.Named("http")
+	db := l.Named("db")
+	pool := db.Named("pool")
+
+	// Act.
+	http.Info("dropped")
+	http.Error(assert.AnError)
+	db.Info("db message")
+	pool.Info("pool message")
+
+	// Assert.
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, `{"level":"error","logger":"http","msg":"assert.AnError general error for testing"}`, lines[0])
+	assert.JSONEq(t, `{"level":"info","logger":"db","msg":"db message"}`, lines[1])
+	assert.JSONEq(t, `{"level":"info","logger":"db.pool","msg":"pool message"}`, lines[2])
+}
+
+func TestLevels_SetLevel(t *testing.T) {
+	// Arrange.
+	var buf bytes.Buffer
+	l := New(&buf, Config{Level: InfoLevel})
+	http := l.Named("http").With(String("key", "val"))
+	levels := l.Levels()
+
+	// Act.
+	_, rootErr := levels.SetLevel("", ErrorLevel, 0)
+	http.Info("follows root, dropped")
+	_, httpErr := levels.SetLevel("http", DebugLevel, 0)
+	http.Info("logged")
+	_, unknownErr := levels.SetLevel("unknown", DebugLevel, 0)
+
+	// Assert.
+	require.NoError(t, rootErr)
+	require.NoError(t, httpErr)
+	assert.ErrorIs(t, unknownErr, ErrUnknownSubsystem)
+	assert.JSONEq(t, `{"level":"info","logger":"http","msg":"logged","key":"val"}`, buf.String())
+	assert.Equal(t, []LevelStatus{
+		{Level: ErrorLevel},
+		{Subsystem: "http", Level: DebugLevel},
+	}, levels.Status())
+}
+
+func TestLevels_Inherit(t *testing.T) {
+	// Arrange.
+	levels := NewLevels(InfoLevel, map[string]Level{"http": DebugLevel})
+
+	// Act.
+	status, err := levels.Inherit("http", 0)
+
+	// Assert.
+	require.NoError(t, err)
+	assert.Equal(t, LevelStatus{Subsystem: "http", Level: InfoLevel, Inherited: true}, status)
+
+	_, err = levels.Inherit("", 0)
+	assert.Error(t, err)
+}
+
+func TestLevels_RevertAfter(t *testing.T) {
+	// Arrange.
+	levels := NewLevels(InfoLevel, map[string]Level{"http": ErrorLevel})
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	levels.now = func() time.Time { return now }
+
+	// Act.
+	first, err := levels.SetLevel("http", DebugLevel, time.Hour)
+	require.NoError(t, err)
+	// A second temporary change still reverts to the level before the first.
+	_, err = levels.SetLevel("http", InfoLevel, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	// Assert.
+	revertAt := now.Add(time.Hour)
+	assert.Equal(t, LevelStatus{Subsystem: "http", Level: DebugLevel, RevertAt: &revertAt}, first)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(LevelStatus{Subsystem: "http", Level: ErrorLevel}, levels.Status()[1])
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestParseSubsystemLevels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		in       []string
+		expected map[string]Level
+		err      bool
+	}{
+		{name: "empty", in: nil, expected: map[string]Level{}},
+		{name: "levels", in: []string{"http=debug", "db=error"}, expected: map[string]Level{"http": DebugLevel, "db": ErrorLevel}},
+		{name: "missing level", in: []string{"http"}, err: true},
+		{name: "invalid level", in: []string{"http=loud"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act.
+			levels, err := ParseSubsystemLevels(tc.in)
+
+			// Assert.
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, levels)
+		})
+	}
+}
@@ -14,28 +14,67 @@ import (
 type Level = zapcore.Level
 
 const (
-	InfoLevel  Level = zap.InfoLevel  // 0, default level
-	ErrorLevel Level = zap.ErrorLevel // 2
-	DebugLevel Level = zap.DebugLevel // -1
+	InfoLevel   Level = zap.InfoLevel   // 0, default level
+	ErrorLevel  Level = zap.ErrorLevel  // 2
+	DebugLevel  Level = zap.DebugLevel  // -1
+	WarnLevel   Level = zap.WarnLevel   // 1
+	DPanicLevel Level = zap.DPanicLevel // 3, panics in development
+	PanicLevel  Level = zap.PanicLevel  // 4
+	FatalLevel  Level = zap.FatalLevel  // 5, exits
 )
 
 type Field = zap.Field
 
 // function variables for all field types in https://github.com/uber-go/zap/blob/master/field.go
 var (
+	Any        = zap.Any
+	Array      = zap.Array
 	Binary     = zap.Binary
 	Bool       = zap.Bool
+	Bools      = zap.Bools
 	ByteString = zap.ByteString
+	Duration   = zap.Duration
+	Durations  = zap.Durations
+	Err        = zap.Error // Logged as "error".
+	Errors     = zap.Errors
 	Float64    = zap.Float64
 	Float32    = zap.Float32
 	Int        = zap.Int
+	Ints       = zap.Ints
+	Int64      = zap.Int64
+	Int32      = zap.Int32
+	Namespace  = zap.Namespace
+	NamedError = zap.NamedError
 	Object     = zap.Object
+	Reflect    = zap.Reflect
+	Skip       = zap.Skip
+	Stack      = zap.Stack
 	String     = zap.String
+	Strings    = zap.Strings
+	Stringer   = zap.Stringer
+	Time       = zap.Time
+	Uint       = zap.Uint
+	Uint64     = zap.Uint64
 )
 
 type Logger struct {
-	l     *zap.Logger // zap ensure that zap.Logger is safe for concurrent use
-	level Level
+	l       *zap.Logger // zap ensure that zap.Logger is safe for concurrent use
+	levels  *Levels     // Shared by the logger and its children, nil for nop.
+	dropped *Dropped    // Shared by the logger and its children.
+	name    string
+}
+
+func (l *Logger) Debug(msg string, fields ...Field) {
+	l.l.Debug(msg, fields...)
+}
+
+func (l *Logger) Debugf(template string, args ...any) {
+	l.l.Sugar().Debugf(template, args...)
+}
+
+// DebugWith logs a message with some additional context, see InfoWith.
+func (l *Logger) DebugWith(msg string, keysAndValues ...interface{}) {
+	l.l.Sugar().Debugw(msg, keysAndValues...)
 }
 
 func (l *Logger) Info(msg string, fields ...Field) {
@@ -52,24 +91,116 @@ func (l *Logger) InfoWith(msg string, keysAndValues ...interface{}) {
 	l.l.Sugar().Infow(msg, keysAndValues...)
 }
 
+func (l *Logger) Warn(msg string, fields ...Field) {
+	l.l.Warn(msg, fields...)
+}
+
+func (l *Logger) Warnf(template string, args ...any) {
+	l.l.Sugar().Warnf(template, args...)
+}
+
+// WarnWith logs a message with some additional context, see InfoWith.
+func (l *Logger) WarnWith(msg string, keysAndValues ...interface{}) {
+	l.l.Sugar().Warnw(msg, keysAndValues...)
+}
+
 func (l *Logger) Error(err error, fields ...Field) {
 	l.l.Error(err.Error(), fields...)
 }
 
+func (l *Logger) Errorf(template string, args ...any) {
+	l.l.Sugar().Errorf(template, args...)
+}
+
 // ErrorWith logs a message with some additional context.
 // The variadic keysAndValues are processed in pairs, the first element of the pair is used as the field key and the second as the field value.
 func (l *Logger) ErrorWith(msg string, keysAndValues ...interface{}) {
 	l.l.Sugar().Errorw(msg, keysAndValues...)
 }
 
+// DPanic logs a message that should never be logged, it then panics in
+// development, see Config.Development.
+func (l *Logger) DPanic(msg string, fields ...Field) {
+	l.l.DPanic(msg, fields...)
+}
+
+func (l *Logger) DPanicf(template string, args ...any) {
+	l.l.Sugar().DPanicf(template, args...)
+}
+
+// DPanicWith logs a message with some additional context, see InfoWith and
+// DPanic.
+func (l *Logger) DPanicWith(msg string, keysAndValues ...interface{}) {
+	l.l.Sugar().DPanicw(msg, keysAndValues...)
+}
+
+// Fatal logs a message and exits with status 1, whatever the level of the
+// logger.
+func (l *Logger) Fatal(msg string, fields ...Field) {
+	l.l.Fatal(msg, fields...)
+}
+
+func (l *Logger) Fatalf(template string, args ...any) {
+	l.l.Sugar().Fatalf(template, args...)
+}
+
+// FatalWith logs a message with some additional context, see InfoWith and
+// Fatal.
+func (l *Logger) FatalWith(msg string, keysAndValues ...interface{}) {
+	l.l.Sugar().Fatalw(msg, keysAndValues...)
+}
+
 // With creates a child logger and adds structured context to it. Fields added to the child don't affect the parent, and vice versa.
 func (l Logger) With(fields ...Field) Logger {
 	return Logger{
-		l:     l.l.With(fields...),
-		level: l.level,
+		l:       l.l.With(fields...),
+		levels:  l.levels,
+		dropped: l.dropped,
+		name:    l.name,
 	}
 }
 
+// Named creates a child logger of the subsystem name, nested in the
+// subsystem of l if any. Its level follows the level of l unless set apart,
+// see Levels.
+func (l *Logger) Named(name string) *Logger {
+	child := &Logger{
+		l:       l.l.Named(name),
+		levels:  l.levels,
+		dropped: l.dropped,
+		name:    name,
+	}
+
+	if l.name != "" {
+		child.name = l.name + "." + name
+	}
+
+	if l.levels != nil {
+		subsystem := l.levels.subsystem(child.name)
+		child.l = child.l.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			if lc, ok := c.(levelCore); ok {
+				return levelCore{Core: lc.Core, level: subsystem}
+			}
+
+			return c
+		}))
+	}
+
+	return child
+}
+
+// Levels returns the levels of the logger and its named subsystems, shared
+// by all loggers derived from the same New.
+func (l *Logger) Levels() *Levels {
+	return l.levels
+}
+
+// Dropped returns the counts of entries dropped by sampling and rate limits,
+// shared by all loggers derived from the same New.
+func (l *Logger) Dropped() *Dropped {
+	return l.dropped
+}
+
 // Sync calls the underlying Zap's Sync method, flushing any buffered log entries. Applications should take care to call Sync before exiting.
 func (l *Logger) Sync() error {
 	return l.l.Sync()
@@ -84,12 +215,37 @@ var (
 
 // Config provides logging configuration.
 type Config struct {
-	Level         Level // Min logged level.
-	WithTimeStamp bool  // Log messages with timestamp.
-	Options       []Option
+	Level         Level            // Min logged level.
+	Levels        map[string]Level // Min logged levels of named subsystems.
+	WithTimeStamp bool             // Log messages with timestamp.
+	Caller        bool             // Log the file and line of the caller.
+	Encoding      Encoding         // Of the writer given to New, JSON if empty.
+	Color         bool             // Color the levels of the console encoding of the writer.
+	Sinks         []Sink           // Outputs in addition to the writer.
+	Sampling      Sampling         // Of entries per message, disabled if zero.
+	RateLimits    []RateLimit      // Of entries per message prefix.
+	// StacktraceLevel is the min level of messages logged with a stack
+	// trace, nil for none.
+	StacktraceLevel *Level
+	// Development makes DPanic panic after logging.
+	Development bool
+	// Options must not wrap the core, which would stop levels from being
+	// changed per subsystem.
+	Options []Option
 }
 
-// New create a new logger given a writer, min logged level and options.
+// Sink is an output of a logger in addition to the writer given to New.
+type Sink struct {
+	Writer   io.Writer // Such as a File.
+	Encoding Encoding  // JSON if empty.
+	Color    bool      // Color the levels of the console encoding.
+	// Level is the min level written to the sink, nil for all levels. It
+	// filters the entries passing the levels of the logger, it cannot lower
+	// them.
+	Level *Level
+}
+
+// New create a new logger given a writer, min logged levels and options.
 // The writer is typically nil, in which case os.Stderr is used.
 func New(writer io.Writer, conf Config) *Logger {
 	if writer == nil {
@@ -105,15 +261,48 @@ func New(writer io.Writer, conf Config) *Logger {
 		zapConfig.EncoderConfig.TimeKey = ""
 	}
 
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(zapConfig.EncoderConfig),
-		zapcore.AddSync(writer),
-		zapcore.Level(conf.Level),
-	)
+	sinks := append([]Sink{{Writer: writer, Encoding: conf.Encoding, Color: conf.Color}}, conf.Sinks...)
+
+	cores := make([]zapcore.Core, 0, len(sinks))
+	for _, sink := range sinks {
+		enabled := zap.LevelEnablerFunc(func(Level) bool { return true })
+		if sink.Level != nil {
+			enabled = zap.LevelEnablerFunc(sink.Level.Enabled)
+		}
+
+		cores = append(cores, zapcore.NewCore(
+			newEncoder(sink.Encoding, sink.Color, zapConfig.EncoderConfig),
+			zapcore.AddSync(sink.Writer),
+			enabled,
+		))
+	}
+
+	levels := NewLevels(conf.Level, conf.Levels)
+	dropped := &Dropped{}
+
+	// Entries are filtered by levels, which may change at runtime, then by
+	// rate limits and sampling, and then by the levels of the sinks.
+	core := levelCore{
+		Core:  newLimitCore(zapcore.NewTee(cores...), conf.Sampling, conf.RateLimits, dropped),
+		level: levels.root,
+	}
+
+	var options []Option
+	if conf.Caller {
+		// Skip the methods of Logger wrapping zap.
+		options = append(options, zap.AddCaller(), zap.AddCallerSkip(1))
+	}
+	if conf.StacktraceLevel != nil {
+		options = append(options, zap.AddStacktrace(*conf.StacktraceLevel))
+	}
+	if conf.Development {
+		options = append(options, zap.Development())
+	}
 
 	logger := &Logger{
-		l:     zap.New(core, conf.Options...),
-		level: conf.Level,
+		l:       zap.New(core, append(options, conf.Options...)...),
+		levels:  levels,
+		dropped: dropped,
 	}
 
 	return logger
@@ -2,10 +2,16 @@ package logging
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestLogLevel(t *testing.T) {
@@ -87,3 +93,165 @@ func TestError(t *testing.T) {
 	// Assert.
 	assert.Equal(t, expected, buf.String())
 }
+
+func TestWithTrace(t *testing.T) {
+	// Arrange.
+	var (
+		buf      bytes.Buffer
+		expected = `{"level":"info","msg":"test message","traceID":"4bf92f3577b34da6a3ce929d0e0e4736","spanID":"00f067aa0ba902b7"}`
+	)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	l := New(&buf, Config{Level: InfoLevel})
+
+	// Act.
+	traced := l.WithTrace(ctx)
+	traced.Info("test message")
+	untraced := l.WithTrace(context.Background())
+
+	// Assert.
+	assert.JSONEq(t, expected, buf.String())
+	assert.Equal(t, *l, untraced)
+}
+
+func TestLevelFamilies(t *testing.T) {
+	// Arrange.
+	testCases := []struct {
+		name     string
+		log      func(l *Logger)
+		expected string
+	}{
+		{
+			name:     "Debug",
+			log:      func(l *Logger) { l.Debug("message", Int("n", 1)) },
+			expected: `{"level":"debug","msg":"message","n":1}`,
+		},
+		{
+			name:     "Debugf",
+			log:      func(l *Logger) { l.Debugf("message %d", 1) },
+			expected: `{"level":"debug","msg":"message 1"}`,
+		},
+		{
+			name:     "DebugWith",
+			log:      func(l *Logger) { l.DebugWith("message", "n", 1) },
+			expected: `{"level":"debug","msg":"message","n":1}`,
+		},
+		{
+			name:     "Warn",
+			log:      func(l *Logger) { l.Warn("message", Duration("d", time.Second)) },
+			expected: `{"level":"warn","msg":"message","d":1}`,
+		},
+		{
+			name:     "Warnf",
+			log:      func(l *Logger) { l.Warnf("message %d", 1) },
+			expected: `{"level":"warn","msg":"message 1"}`,
+		},
+		{
+			name:     "WarnWith",
+			log:      func(l *Logger) { l.WarnWith("message", "n", 1) },
+			expected: `{"level":"warn","msg":"message","n":1}`,
+		},
+		{
+			name:     "Errorf",
+			log:      func(l *Logger) { l.Errorf("message %d", 1) },
+			expected: `{"level":"error","msg":"message 1"}`,
+		},
+		{
+			name:     "DPanic",
+			log:      func(l *Logger) { l.DPanic("message", Err(errors.New("failed"))) },
+			expected: `{"level":"dpanic","msg":"message","error":"failed"}`,
+		},
+		{
+			name:     "DPanicf",
+			log:      func(l *Logger) { l.DPanicf("message %d", 1) },
+			expected: `{"level":"dpanic","msg":"message 1"}`,
+		},
+		{
+			name:     "DPanicWith",
+			log:      func(l *Logger) { l.DPanicWith("message", "n", 1) },
+			expected: `{"level":"dpanic","msg":"message","n":1}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, Config{Level: DebugLevel})
+
+			// Act.
+			tc.log(l)
+
+			// Assert.
+			assert.JSONEq(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestDevelopmentDPanic(t *testing.T) {
+	// Arrange.
+	var buf bytes.Buffer
+	l := New(&buf, Config{Level: InfoLevel, Development: true})
+
+	// Act & Assert.
+	assert.Panics(t, func() { l.DPanic("message") })
+	assert.Contains(t, buf.String(), `"level":"dpanic"`)
+}
+
+func TestCaller(t *testing.T) {
+	// Arrange.
+	testCases := []struct {
+		name string
+		log  func(l *Logger)
+	}{
+		{name: "Info", log: func(l *Logger) { l.Info("message") }},
+		{name: "Infof", log: func(l *Logger) { l.Infof("message") }},
+		{name: "InfoWith", log: func(l *Logger) { l.InfoWith("message") }},
+		{name: "InfoCtx", log: func(l *Logger) { l.InfoCtx(context.Background(), "message") }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				buf   bytes.Buffer
+				l     = New(&buf, Config{Level: InfoLevel, Caller: true})
+				entry map[string]any
+			)
+
+			// Act.
+			tc.log(l)
+
+			// Assert.
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Contains(t, entry["caller"], "logging/logger_test.go:")
+		})
+	}
+}
+
+func TestStacktraceLevel(t *testing.T) {
+	// Arrange.
+	var (
+		buf   bytes.Buffer
+		level = WarnLevel
+		l     = New(&buf, Config{Level: InfoLevel, StacktraceLevel: &level})
+		info  map[string]any
+		warn  map[string]any
+	)
+
+	// Act.
+	l.Info("message")
+	infoLine := buf.String()
+	buf.Reset()
+	l.Warn("message")
+
+	// Assert.
+	require.NoError(t, json.Unmarshal([]byte(infoLine), &info))
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &warn))
+	assert.NotContains(t, info, "stacktrace")
+	assert.Contains(t, warn["stacktrace"], "TestStacktraceLevel")
+}
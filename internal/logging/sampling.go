@@ -0,0 +1,220 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Sampling limits the entries logged per level and message, logging the
+// First entries of every Tick and then every Thereafter-th.
+type Sampling struct {
+	Tick       time.Duration // A second if 0.
+	First      int           // Entries logged per tick before sampling, 0 disables sampling.
+	Thereafter int           // Every Thereafter-th entry is logged after First, none if 0.
+}
+
+// RateLimit limits the entries of the messages starting with Key to Limit
+// per Window, whatever their level.
+type RateLimit struct {
+	Key    string
+	Limit  int
+	Window time.Duration
+}
+
+// ParseRateLimits parses "key=limit/window" entries such as
+// "Response: 5=10/1s", the key being a message prefix.
+func ParseRateLimits(values []string) ([]RateLimit, error) {
+	limits := make([]RateLimit, 0, len(values))
+	for _, v := range values {
+		i := strings.LastIndex(v, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("%q must be formatted as key=limit/window", v)
+		}
+
+		count, window, ok := strings.Cut(v[i+1:], "/")
+		limit, err := strconv.Atoi(count)
+		if !ok || err != nil || limit < 0 {
+			return nil, fmt.Errorf("limit of %q must be formatted as limit/window", v)
+		}
+
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("window of %q must be a positive duration", v)
+		}
+
+		limits = append(limits, RateLimit{Key: v[:i], Limit: limit, Window: d})
+	}
+
+	return limits, nil
+}
+
+// Dropped counts the entries dropped by sampling and rate limits, shared by
+// all loggers derived from the same New.
+type Dropped struct {
+	sampled     atomic.Uint64
+	rateLimited atomic.Uint64
+}
+
+// Sampled returns the number of entries dropped by sampling.
+func (d *Dropped) Sampled() uint64 {
+	return d.sampled.Load()
+}
+
+// RateLimited returns the number of entries dropped by rate limits.
+func (d *Dropped) RateLimited() uint64 {
+	return d.rateLimited.Load()
+}
+
+// ReportDropped warns every interval of the entries dropped since the
+// previous report, if any, until ctx is done.
+func (l *Logger) ReportDropped(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var sampled, rateLimited uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s, r := l.dropped.Sampled(), l.dropped.RateLimited()
+		if s == sampled && r == rateLimited {
+			continue
+		}
+
+		l.Warn("log entries dropped",
+			Uint64("sampled", s-sampled),
+			Uint64("rateLimited", r-rateLimited),
+			Duration("interval", interval),
+		)
+
+		sampled, rateLimited = s, r
+	}
+}
+
+// rateLimits are fixed window counters of the keys of RateLimit.
+type rateLimits struct {
+	limits []RateLimit // Longest key first.
+
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func newRateLimits(limits []RateLimit) *rateLimits {
+	sorted := append([]RateLimit(nil), limits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].Key) > len(sorted[j].Key) })
+
+	return &rateLimits{limits: sorted, windows: map[string]*window{}}
+}
+
+// allow reports whether an entry of msg logged at t is within the limit of
+// the longest key prefixing msg, counting it if so.
+func (rl *rateLimits) allow(msg string, t time.Time) bool {
+	for _, l := range rl.limits {
+		if !strings.HasPrefix(msg, l.Key) {
+			continue
+		}
+
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+
+		w, ok := rl.windows[l.Key]
+		if !ok || t.Sub(w.start) >= l.Window {
+			w = &window{start: t}
+			rl.windows[l.Key] = w
+		}
+
+		if w.count >= l.Limit {
+			return false
+		}
+
+		w.count++
+		return true
+	}
+
+	return true
+}
+
+// limitCore drops the entries of a core exceeding the rate limits of their
+// messages, and then samples them. Entries at DPanic level and above are
+// never dropped.
+type limitCore struct {
+	zapcore.Core
+	sampled zapcore.Core // The core behind a sampler, nil without sampling.
+	limits  *rateLimits  // nil without rate limits.
+	dropped *Dropped
+}
+
+func newLimitCore(core zapcore.Core, sampling Sampling, limits []RateLimit, dropped *Dropped) zapcore.Core {
+	if sampling.First <= 0 && len(limits) == 0 {
+		return core
+	}
+
+	c := limitCore{Core: core, dropped: dropped}
+
+	if sampling.First > 0 {
+		tick, thereafter := sampling.Tick, sampling.Thereafter
+		if tick <= 0 {
+			tick = time.Second
+		}
+		if thereafter <= 0 {
+			// Out of reach, no entry is logged past First.
+			thereafter = int(^uint(0) >> 1)
+		}
+
+		c.sampled = zapcore.NewSamplerWithOptions(core, tick, sampling.First, thereafter,
+			zapcore.SamplerHook(func(_ zapcore.Entry, dec zapcore.SamplingDecision) {
+				if dec&zapcore.LogDropped != 0 {
+					dropped.sampled.Add(1)
+				}
+			}),
+		)
+	}
+
+	if len(limits) > 0 {
+		c.limits = newRateLimits(limits)
+	}
+
+	return c
+}
+
+func (c limitCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := limitCore{Core: c.Core.With(fields), limits: c.limits, dropped: c.dropped}
+	if c.sampled != nil {
+		clone.sampled = c.sampled.With(fields)
+	}
+
+	return clone
+}
+
+func (c limitCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if e.Level >= DPanicLevel || !c.Enabled(e.Level) {
+		return c.Core.Check(e, ce)
+	}
+
+	if c.limits != nil && !c.limits.allow(e.Message, e.Time) {
+		c.dropped.rateLimited.Add(1)
+		return ce
+	}
+
+	if c.sampled != nil {
+		return c.sampled.Check(e, ce)
+	}
+
+	return c.Core.Check(e, ce)
+}
@@ -0,0 +1,174 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	// Arrange.
+	testCases := []struct {
+		name     string
+		in       []string
+		expected []RateLimit
+		err      bool
+	}{
+		{
+			name:     "valid",
+			in:       []string{"Response: 5=10/1s", "a=b=0/1m"},
+			expected: []RateLimit{{Key: "Response: 5", Limit: 10, Window: time.Second}, {Key: "a=b", Limit: 0, Window: time.Minute}},
+		},
+		{name: "missing key", in: []string{"=10/1s"}, err: true},
+		{name: "missing window", in: []string{"key=10"}, err: true},
+		{name: "negative limit", in: []string{"key=-1/1s"}, err: true},
+		{name: "zero window", in: []string{"key=1/0s"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act.
+			limits, err := ParseRateLimits(tc.in)
+
+			// Assert.
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, limits)
+		})
+	}
+}
+
+func TestSampling(t *testing.T) {
+	// Arrange.
+	var buf bytes.Buffer
+	l := New(&buf, Config{
+		Level:    InfoLevel,
+		Sampling: Sampling{Tick: time.Hour, First: 2, Thereafter: 3},
+	})
+
+	// Act.
+	for i := 0; i < 10; i++ {
+		l.Info("hot")
+		l.Warn("hot")
+	}
+	l.Info("cold")
+
+	// Assert.
+	assert.Equal(t, 4, strings.Count(buf.String(), `{"level":"info","msg":"hot"}`)) // 1, 2, 5 and 8.
+	assert.Equal(t, 4, strings.Count(buf.String(), `{"level":"warn","msg":"hot"}`))
+	assert.Contains(t, buf.String(), `"msg":"cold"`)
+	assert.Equal(t, uint64(12), l.Dropped().Sampled())
+}
+
+func TestSamplingNeverDropsDPanic(t *testing.T) {
+	// Arrange.
+	var buf bytes.Buffer
+	l := New(&buf, Config{Level: InfoLevel, Sampling: Sampling{Tick: time.Hour, First: 1}})
+
+	// Act.
+	for i := 0; i < 3; i++ {
+		l.DPanic("hot")
+	}
+
+	// Assert.
+	assert.Equal(t, 3, strings.Count(buf.String(), `"msg":"hot"`))
+	assert.Zero(t, l.Dropped().Sampled())
+}
+
+func TestRateLimits(t *testing.T) {
+	// Arrange.
+	var buf bytes.Buffer
+	l := New(&buf, Config{
+		Level: InfoLevel,
+		RateLimits: []RateLimit{
+			{Key: "Response: ", Limit: 3, Window: time.Hour},
+			{Key: "Response: 5", Limit: 1, Window: time.Hour},
+		},
+	})
+
+	// Act.
+	for i := 0; i < 5; i++ {
+		l.Info("Response: 200 OK")
+		l.ErrorWith("Response: 500 Internal Server Error")
+		l.ErrorWith("Response: 503 Service Unavailable")
+	}
+	l.Info("Request: GET /")
+
+	// Assert.
+	assert.Equal(t, 3, strings.Count(buf.String(), "Response: 200"))
+	assert.Equal(t, 1, strings.Count(buf.String(), "Response: 5")) // Shared by the 5xx.
+	assert.Contains(t, buf.String(), "Request: GET /")
+	assert.Equal(t, uint64(11), l.Dropped().RateLimited())
+	assert.Zero(t, l.Dropped().Sampled())
+}
+
+func TestRateLimitWindow(t *testing.T) {
+	// Arrange.
+	var (
+		rl    = newRateLimits([]RateLimit{{Key: "hot", Limit: 1, Window: time.Minute}})
+		start = time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	)
+
+	// Act & Assert.
+	assert.True(t, rl.allow("hot", start))
+	assert.False(t, rl.allow("hot", start.Add(59*time.Second)))
+	assert.True(t, rl.allow("hot", start.Add(time.Minute)))
+	assert.True(t, rl.allow("cold", start))
+}
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestReportDropped(t *testing.T) {
+	// Arrange.
+	var buf syncBuffer
+	l := New(&buf, Config{Level: InfoLevel, Sampling: Sampling{Tick: time.Hour, First: 1}})
+
+	l.Info("hot")
+	l.Info("hot")
+	l.Info("hot")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	// Act.
+	go func() {
+		l.ReportDropped(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	// Assert.
+	assert.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), `{"level":"warn","msg":"log entries dropped","sampled":2,"rateLimited":0,"interval":0.01}`)
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+
+	// Reported once as no entry was dropped since.
+	assert.Equal(t, 1, strings.Count(buf.String(), "log entries dropped"))
+}
@@ -0,0 +1,22 @@
+package logging
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTrace creates a child logger with the trace and span ID of the span in
+// ctx, so logs can be correlated with traces. The logger is returned as is
+// when ctx carries no valid span context.
+func (l Logger) WithTrace(ctx context.Context) Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+
+	return l.With(
+		String("traceID", sc.TraceID().String()),
+		String("spanID", sc.SpanID().String()),
+	)
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute labels requests not matching any route, so arbitrary paths
+// don't create new label values.
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels requests of non-standard methods, as the server
+// accepts any method token.
+const otherMethod = "OTHER"
+
+// HTTPMetrics holds the RED metrics of http requests labeled by route
+// pattern rather than path.
+type HTTPMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+func NewHTTPMetrics(reg prometheus.Registerer) *HTTPMetrics {
+	m := &HTTPMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of handled http requests.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of handled http requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of http requests currently being handled.",
+		}, []string{"method", "route"}),
+	}
+
+	reg.MustRegister(m.requests, m.duration, m.inFlight)
+
+	return m
+}
+
+// Middleware instruments requests, it must be used on the chi router the
+// routes are registered on.
+func (m *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		var (
+			route  = routePattern(r)
+			method = methodLabel(r.Method)
+			ww     = chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			t1     = time.Now()
+		)
+
+		inFlight := m.inFlight.WithLabelValues(method, route)
+		inFlight.Inc()
+
+		defer func() {
+			inFlight.Dec()
+
+			status := ww.Status()
+			if status == 0 {
+				// Nothing written means an implicit 200.
+				status = http.StatusOK
+			}
+
+			labels := []string{method, route, strconv.Itoa(status)}
+			m.requests.WithLabelValues(labels...).Inc()
+			m.duration.WithLabelValues(labels...).Observe(time.Since(t1).Seconds())
+		}()
+
+		next.ServeHTTP(ww, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// routePattern resolves the route pattern of r, e.g. /api/example/{id}, up
+// front so it is also known for in-flight requests.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return unmatchedRoute
+	}
+
+	tctx := chi.NewRouteContext()
+	if !rctx.Routes.Match(tctx, r.Method, r.URL.Path) {
+		return unmatchedRoute
+	}
+
+	return tctx.RoutePattern()
+}
+
+// methodLabel returns method if standard, otherwise otherMethod.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return otherMethod
+	}
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMetricsMiddleware(t *testing.T) {
+	// Arrange
+	reg := prometheus.NewRegistry()
+	m := NewHTTPMetrics(reg)
+
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+		r.Post("/items", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedMethod string
+		expectedRoute  string
+		expectedStatus string
+	}{
+		{
+			name:           "should label by route pattern",
+			method:         http.MethodGet,
+			expectedMethod: http.MethodGet,
+			path:           "/api/items/1",
+			expectedRoute:  "/api/items/{id}",
+			expectedStatus: "200",
+		},
+		{
+			name:           "should label written status",
+			method:         http.MethodPost,
+			expectedMethod: http.MethodPost,
+			path:           "/api/items",
+			expectedRoute:  "/api/items",
+			expectedStatus: "201",
+		},
+		{
+			name:           "should label unknown paths as unmatched",
+			method:         http.MethodGet,
+			expectedMethod: http.MethodGet,
+			path:           "/does/not/exist",
+			expectedRoute:  unmatchedRoute,
+			expectedStatus: "404",
+		},
+		{
+			name:           "should label non-standard methods as other",
+			method:         "MADEUP",
+			path:           "/api/items",
+			expectedMethod: otherMethod,
+			expectedRoute:  unmatchedRoute,
+			expectedStatus: "405",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			// Assert
+			requests := m.requests.WithLabelValues(tt.expectedMethod, tt.expectedRoute, tt.expectedStatus)
+			assert.Equal(t, 1.0, testutil.ToFloat64(requests))
+
+			inFlight := m.inFlight.WithLabelValues(tt.expectedMethod, tt.expectedRoute)
+			assert.Equal(t, 0.0, testutil.ToFloat64(inFlight))
+
+			// No series of the method of the client.
+			for _, labels := range seriesLabels(t, reg, "http_requests_total") {
+				assert.Contains(t, []string{http.MethodGet, http.MethodPost, otherMethod}, labels["method"])
+			}
+		})
+	}
+}
+
+// seriesLabels returns the labels of the series of the metric name in reg.
+func seriesLabels(t *testing.T, reg *prometheus.Registry, name string) []map[string]string {
+	t.Helper()
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	var series []map[string]string
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			series = append(series, labels)
+		}
+	}
+
+	return series
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+// logDroppedCollector collects the counts of dropped log entries.
+type logDroppedCollector struct {
+	dropped *logging.Dropped
+	desc    *prometheus.Desc
+}
+
+// NewLogDroppedCollector returns a collector of the log entries dropped by
+// sampling and rate limits, labeled by reason.
+func NewLogDroppedCollector(dropped *logging.Dropped) prometheus.Collector {
+	return &logDroppedCollector{
+		dropped: dropped,
+		desc:    prometheus.NewDesc("log_entries_dropped_total", "Number of log entries dropped by sampling or rate limits.", []string{"reason"}, nil),
+	}
+}
+
+func (c *logDroppedCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *logDroppedCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(c.dropped.Sampled()), "sampling")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(c.dropped.RateLimited()), "rate_limit")
+}
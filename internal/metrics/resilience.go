@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bratteby/go-service-template/internal/resilience"
+)
+
+// ResilienceMetrics holds the metrics of retries and circuit breakers,
+// labeled by the name of the protected dependency.
+type ResilienceMetrics struct {
+	state       *prometheus.GaugeVec
+	transitions *prometheus.CounterVec
+	retries     *prometheus.CounterVec
+}
+
+func NewResilienceMetrics(reg prometheus.Registerer) *ResilienceMetrics {
+	m := &ResilienceMetrics{
+		state: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "State of circuit breakers, 0 closed, 1 half-open and 2 open.",
+		}, []string{"name"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "circuit_breaker_transitions_total",
+			Help: "Number of state transitions of circuit breakers.",
+		}, []string{"name", "from", "to"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retries_total",
+			Help: "Number of retried calls.",
+		}, []string{"name"}),
+	}
+
+	reg.MustRegister(m.state, m.transitions, m.retries)
+
+	return m
+}
+
+// StateChanged records a transition of the breaker name, for
+// resilience.Breaker.OnStateChange.
+func (m *ResilienceMetrics) StateChanged(name string, from, to resilience.State) {
+	m.state.WithLabelValues(name).Set(float64(to))
+	m.transitions.WithLabelValues(name, from.String(), to.String()).Inc()
+}
+
+// Retried records a retry of a call to name.
+func (m *ResilienceMetrics) Retried(name string) {
+	m.retries.WithLabelValues(name).Inc()
+}
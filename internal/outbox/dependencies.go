@@ -0,0 +1,23 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//go:generate moq -out mock_store_test.go . store
+type store interface {
+	// ClaimPending returns up to limit pending events due at now, oldest
+	// first, leased to the caller by postponing their next attempt to
+	// leaseUntil, so other relays skip them while they are published.
+	ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]PendingEvent, error)
+	MarkPublished(ctx context.Context, id uuid.UUID, at time.Time) error
+	// MarkFailed records a failed publish attempt, the event is retried at
+	// retryAt.
+	MarkFailed(ctx context.Context, id uuid.UUID, cause error, retryAt time.Time) error
+	// MarkDead records the last failed publish attempt, the event is no
+	// longer published.
+	MarkDead(ctx context.Context, id uuid.UUID, cause error, at time.Time) error
+}
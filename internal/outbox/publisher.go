@@ -0,0 +1,97 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+// WriterPublisher publishes events as JSON lines to W, e.g. stdout or a file.
+type WriterPublisher struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, e example.Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("could not encode event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.W.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("could not write event: %w", err)
+	}
+
+	return nil
+}
+
+// EventIDHeader carries the event ID of webhook requests, for receivers to
+// dedupe redelivered events.
+const EventIDHeader = "X-Event-ID"
+
+// WebhookPublisher publishes events by POSTing them as JSON to URL, any
+// non 2xx response fails the publish.
+type WebhookPublisher struct {
+	URL    string
+	Client *http.Client // http.DefaultClient if nil.
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, e example.Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("could not encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("could not create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, e.ID.String())
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Publishers publishes events to all of its publishers. Publishing fails if
+// any fails, the event is then published to all of them again, so each
+// must tolerate duplicates.
+type Publishers []Publisher
+
+func (ps Publishers) Publish(ctx context.Context, e example.Event) error {
+	for _, p := range ps {
+		if err := p.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
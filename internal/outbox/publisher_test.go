@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	event := example.Event{
+		ID:   uuid.New(),
+		Type: example.EventExampleCreated,
+		Data: json.RawMessage(`{"name":"Test"}`),
+	}
+
+	tests := []struct {
+		name        string
+		givenStatus int
+		expectError bool
+	}{
+		{
+			name:        "should publish on 2xx",
+			givenStatus: http.StatusAccepted,
+		},
+		{
+			name:        "should fail on non 2xx",
+			givenStatus: http.StatusBadGateway,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var (
+				gotEventID string
+				got        example.Event
+			)
+
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotEventID = r.Header.Get(EventIDHeader)
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.givenStatus)
+			}))
+			defer receiver.Close()
+
+			p := &WebhookPublisher{URL: receiver.URL}
+
+			// Act
+			err := p.Publish(context.Background(), event)
+
+			// Assert
+			assert.Equal(t, event.ID.String(), gotEventID)
+			assert.Equal(t, event.ID, got.ID)
+			assert.JSONEq(t, `{"name":"Test"}`, string(got.Data))
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWriterPublisher(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	p := &WriterPublisher{W: &buf}
+
+	event := example.Event{ID: uuid.New(), Type: example.EventExampleDeleted, Data: json.RawMessage(`{}`)}
+
+	// Act
+	require.NoError(t, p.Publish(context.Background(), event))
+	require.NoError(t, p.Publish(context.Background(), event))
+
+	// Assert
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2, "should write one event per line")
+
+	var got example.Event
+	require.NoError(t, json.Unmarshal(lines[0], &got))
+	assert.Equal(t, event.ID, got.ID)
+}
@@ -0,0 +1,177 @@
+// Package outbox relays the domain events stored in the transactional outbox
+// to a Publisher, delivering every event at least once.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/logging"
+	"github.com/bratteby/go-service-template/internal/resilience"
+)
+
+const (
+	DefaultPollInterval = 5 * time.Second
+	DefaultBatchSize    = 100
+	DefaultMaxAttempts  = 20
+	DefaultBaseBackoff  = time.Second
+	DefaultMaxBackoff   = 10 * time.Minute
+	DefaultLease        = 5 * time.Minute
+)
+
+// Publisher publishes an event. An event may be published more than once,
+// e.g. when the relay stops after publishing but before marking the event
+// published, so consumers should dedupe events by ID.
+type Publisher interface {
+	Publish(ctx context.Context, e example.Event) error
+}
+
+// PendingEvent is an unpublished event with its failed publish attempts.
+type PendingEvent struct {
+	example.Event
+	Attempts int
+}
+
+// Relay publishes pending events of the outbox. Events failing to publish
+// are retried with exponential backoff until MaxAttempts, after which they
+// are dead and left for an operator to inspect.
+//
+// Events are leased rather than locked while published, so no transaction
+// is held open during publishing. An event whose result could not be
+// recorded, e.g. as the relay stopped, is published again once its lease
+// expires.
+type Relay struct {
+	Store     store
+	Publisher Publisher
+	Logger    *logging.Logger
+
+	// PollInterval between looking for pending events, DefaultPollInterval
+	// if zero.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of events claimed at once,
+	// DefaultBatchSize if zero.
+	BatchSize int
+	// Notifications wakes the relay up before the poll interval has passed,
+	// e.g. on a postgres notification of a new event. Optional.
+	Notifications <-chan struct{}
+
+	// Zero values are replaced by the defaults.
+	MaxAttempts int
+	BaseBackoff time.Duration // Delay after the first failed attempt, doubled on every attempt.
+	MaxBackoff  time.Duration
+	// Lease hides claimed events from other relays while published, it
+	// should exceed the time to publish a batch.
+	Lease time.Duration
+
+	now func() time.Time // For tests, time.Now if nil.
+}
+
+// Run relays events until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	interval := r.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.RelayBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.Logger.Error(fmt.Errorf("could not relay outbox events: %w", err))
+			}
+
+			// A full batch published means more events are likely pending,
+			// failed events wait for their backoff instead.
+			if err != nil || n < r.batchSize() {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.Notifications:
+		}
+	}
+}
+
+// RelayBatch publishes a batch of due events in order and returns the
+// number of events published. Events failing to publish are retried after
+// a backoff.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	now := r.clock()
+
+	events, err := r.Store.ClaimPending(ctx, now, now.Add(resilience.OrDefault(r.Lease, DefaultLease)), r.batchSize())
+	if err != nil {
+		return 0, fmt.Errorf("could not claim pending events: %w", err)
+	}
+
+	var published int
+	for _, e := range events {
+		if err := r.Publisher.Publish(ctx, e.Event); err != nil {
+			if err := r.markFailed(ctx, e, err); err != nil {
+				return published, err
+			}
+
+			continue
+		}
+
+		if err := r.Store.MarkPublished(ctx, e.ID, r.clock()); err != nil {
+			return published, fmt.Errorf("could not mark event %s published: %w", e.ID, err)
+		}
+
+		published++
+	}
+
+	return published, nil
+}
+
+// markFailed records a failed publish of e, retried after a backoff or dead
+// after MaxAttempts.
+func (r *Relay) markFailed(ctx context.Context, e PendingEvent, cause error) error {
+	attempts := e.Attempts + 1
+
+	if attempts >= resilience.OrDefault(r.MaxAttempts, DefaultMaxAttempts) {
+		r.Logger.ErrorWith("outbox event is dead", "eventID", e.ID.String(), "type", string(e.Type), "attempts", attempts, "error", cause.Error())
+
+		if err := r.Store.MarkDead(ctx, e.ID, cause, r.clock()); err != nil {
+			return fmt.Errorf("could not mark event %s dead: %w", e.ID, err)
+		}
+
+		return nil
+	}
+
+	r.Logger.ErrorWith("could not publish event", "eventID", e.ID.String(), "type", string(e.Type), "attempts", attempts, "error", cause.Error())
+
+	if err := r.Store.MarkFailed(ctx, e.ID, cause, r.clock().Add(r.backoff(attempts))); err != nil {
+		return fmt.Errorf("could not mark event %s failed: %w", e.ID, err)
+	}
+
+	return nil
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (r *Relay) backoff(attempts int) time.Duration {
+	return resilience.Backoff{
+		Base: resilience.OrDefault(r.BaseBackoff, DefaultBaseBackoff),
+		Max:  resilience.OrDefault(r.MaxBackoff, DefaultMaxBackoff),
+	}.Delay(attempts)
+}
+
+func (r *Relay) batchSize() int {
+	return resilience.OrDefault(r.BatchSize, DefaultBatchSize)
+}
+
+func (r *Relay) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+
+	return time.Now().UTC()
+}
@@ -0,0 +1,165 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+type publisherFunc func(ctx context.Context, e example.Event) error
+
+func (f publisherFunc) Publish(ctx context.Context, e example.Event) error {
+	return f(ctx, e)
+}
+
+func TestRelayBatch(t *testing.T) {
+	// Arrange
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	events := []PendingEvent{
+		{Event: example.Event{ID: uuid.New(), Type: example.EventExampleCreated}},
+		{Event: example.Event{ID: uuid.New(), Type: example.EventExampleCreated}, Attempts: 1},
+		{Event: example.Event{ID: uuid.New(), Type: example.EventExampleUpdated}},
+		{Event: example.Event{ID: uuid.New(), Type: example.EventExampleDeleted}, Attempts: 2},
+	}
+
+	store := &storeMock{
+		ClaimPendingFunc: func(ctx context.Context, at, leaseUntil time.Time, limit int) ([]PendingEvent, error) {
+			return events, nil
+		},
+		MarkPublishedFunc: func(ctx context.Context, id uuid.UUID, at time.Time) error {
+			return nil
+		},
+		MarkFailedFunc: func(ctx context.Context, id uuid.UUID, cause error, retryAt time.Time) error {
+			return nil
+		},
+		MarkDeadFunc: func(ctx context.Context, id uuid.UUID, cause error, at time.Time) error {
+			return nil
+		},
+	}
+
+	var published []uuid.UUID
+	publisher := publisherFunc(func(ctx context.Context, e example.Event) error {
+		if e.ID == events[1].ID || e.ID == events[3].ID {
+			return errors.New("receiver down")
+		}
+
+		published = append(published, e.ID)
+		return nil
+	})
+
+	r := &Relay{
+		Store:       store,
+		Publisher:   publisher,
+		Logger:      logging.New(io.Discard, logging.Config{}),
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseBackoff: time.Minute,
+		MaxBackoff:  time.Hour,
+		Lease:       time.Minute,
+		now:         func() time.Time { return now },
+	}
+
+	// Act
+	n, err := r.RelayBatch(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []uuid.UUID{events[0].ID, events[2].ID}, published, "should continue after a failed event")
+
+	require.Len(t, store.ClaimPendingCalls(), 1)
+	assert.Equal(t, 10, store.ClaimPendingCalls()[0].Limit)
+	assert.Equal(t, now.Add(time.Minute), store.ClaimPendingCalls()[0].LeaseUntil)
+
+	var marked []uuid.UUID
+	for _, c := range store.MarkPublishedCalls() {
+		marked = append(marked, c.ID)
+	}
+	assert.Equal(t, published, marked)
+
+	require.Len(t, store.MarkFailedCalls(), 1)
+	failed := store.MarkFailedCalls()[0]
+	assert.Equal(t, events[1].ID, failed.ID)
+	assert.True(t, failed.RetryAt.After(now.Add(time.Minute)), "second failure should back off more than the base backoff")
+	assert.False(t, failed.RetryAt.After(now.Add(2*time.Minute)))
+
+	require.Len(t, store.MarkDeadCalls(), 1)
+	assert.Equal(t, events[3].ID, store.MarkDeadCalls()[0].ID)
+}
+
+func TestRunStopsOnFailedBatch(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &storeMock{
+		ClaimPendingFunc: func(ctx context.Context, at, leaseUntil time.Time, limit int) ([]PendingEvent, error) {
+			events := make([]PendingEvent, limit)
+			for i := range events {
+				events[i] = PendingEvent{Event: example.Event{ID: uuid.New()}}
+			}
+			return events, nil
+		},
+		MarkFailedFunc: func(ctx context.Context, id uuid.UUID, cause error, retryAt time.Time) error {
+			return nil
+		},
+	}
+
+	r := &Relay{
+		Store: store,
+		Publisher: publisherFunc(func(ctx context.Context, e example.Event) error {
+			return errors.New("receiver down")
+		}),
+		Logger:       logging.New(io.Discard, logging.Config{}),
+		BatchSize:    2,
+		PollInterval: time.Hour,
+	}
+
+	done := make(chan struct{})
+
+	// Act
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	// Assert
+	assert.Len(t, store.ClaimPendingCalls(), 1, "a full batch of failed events should wait for the next poll")
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := &Relay{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	tests := []struct {
+		attempts int
+		max      time.Duration
+	}{
+		{attempts: 1, max: time.Second},
+		{attempts: 2, max: 2 * time.Second},
+		{attempts: 3, max: 4 * time.Second},
+		{attempts: 10, max: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		// Act
+		backoff := r.backoff(tt.attempts)
+
+		// Assert
+		assert.LessOrEqual(t, backoff, tt.max, "attempts %d", tt.attempts)
+		assert.GreaterOrEqual(t, backoff, tt.max*4/5, "attempts %d", tt.attempts)
+	}
+}
@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+)
+
+type APIKeyRepository struct {
+	DB pool
+}
+
+// FindAPIKeyByHash returns the non revoked API key with the given hash.
+func (r *APIKeyRepository) FindAPIKeyByHash(ctx context.Context, hash string) (auth.APIKey, error) {
+	query := `
+		SELECT id, name, scopes
+		FROM api_key
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+
+	var key auth.APIKey
+	err := r.DB.QueryRow(ctx, query, hash).Scan(&key.ID, &key.Name, &key.Scopes)
+	if err == pgx.ErrNoRows {
+		return auth.APIKey{}, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return auth.APIKey{}, wrapPgxError(err)
+	}
+
+	return key, nil
+}
@@ -1,9 +1,10 @@
 package postgres
 
 import (
-	"net"
-
+	"context"
 	"errors"
+	"fmt"
+	"net"
 
 	"github.com/bratteby/go-service-template/internal/example"
 	"github.com/jackc/pgconn"
@@ -11,27 +12,93 @@ import (
 	"github.com/jackc/pgx/v4"
 )
 
+// wrapPgxError classifies err by wrapping it with the matching API error,
+// errors of unknown cause are returned as is and end up as internal errors.
 func wrapPgxError(err error) error {
-	if err == pgx.ErrNoRows {
+	if errors.Is(err, pgx.ErrNoRows) {
 		return example.WrapError(err, example.ErrNotFound)
 	}
 
 	var opError *net.OpError
 	if errors.As(err, &opError) {
 		if opError.Op == "dial" {
-			return example.WrapError(err, example.ErrTemporary)
+			return example.Unavailable(example.WrapError(err, example.ErrTemporary))
 		}
 	}
 
-	var pgxError *pgconn.PgError
-	if errors.As(err, &pgxError) {
-		code := pgxError.Code
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return wrapPgError(err, pgErr)
+	}
 
-		switch {
-		case pgerrcode.IsConnectionException(code), pgerrcode.IsConnectionException(code):
-			return example.WrapError(err, example.ErrTemporary)
-		}
+	// Canceled or timed out by the context, either within pgconn or while
+	// waiting for a connection of the pool.
+	if pgconn.Timeout(err) || errors.Is(err, context.DeadlineExceeded) {
+		return example.WrapError(err, example.ErrTimeout)
 	}
 
 	return err
 }
+
+// wrapPgError classifies err by the SQLSTATE code of the server error pgErr
+// it wraps.
+func wrapPgError(err error, pgErr *pgconn.PgError) error {
+	code := pgErr.Code
+
+	switch {
+	case code == pgerrcode.UniqueViolation:
+		return example.WrapErrorDetail(
+			fmt.Errorf("unique constraint %s violated: %w", pgErr.ConstraintName, err),
+			example.ErrConflict,
+			fmt.Sprintf("conflicts with an existing resource (%s)", pgErr.ConstraintName),
+		)
+
+	case code == pgerrcode.NotNullViolation:
+		return constraintError(err, pgErr.ColumnName, "is required")
+
+	case code == pgerrcode.ForeignKeyViolation:
+		return constraintError(err, constraintField(pgErr), "references a resource that does not exist")
+
+	case code == pgerrcode.CheckViolation:
+		return constraintError(err, constraintField(pgErr), fmt.Sprintf("violates constraint %s", pgErr.ConstraintName))
+
+	// Retrying the transaction may succeed, see TxManager. The server is
+	// fine though, contention is not a sign of it being unavailable.
+	case code == pgerrcode.SerializationFailure, code == pgerrcode.DeadlockDetected:
+		return example.WrapError(err, example.ErrTemporary)
+
+	// Statement timeout or cancellation of the query.
+	case code == pgerrcode.QueryCanceled:
+		return example.WrapError(err, example.ErrTimeout)
+
+	case pgerrcode.IsConnectionException(code),
+		pgerrcode.IsInsufficientResources(code),
+		pgerrcode.IsOperatorIntervention(code):
+		return example.Unavailable(example.WrapError(err, example.ErrTemporary))
+
+	// The schema and grants of the service are out of sync, no retry fixes
+	// that.
+	case code == pgerrcode.InsufficientPrivilege:
+		return example.Alert(fmt.Errorf("insufficient privilege: %w", err))
+	}
+
+	return err
+}
+
+// constraintError returns a validation error of field wrapping err.
+func constraintError(err error, field, message string) error {
+	var v example.ValidationError
+	v.Add(field, message)
+
+	return fmt.Errorf("%w: %v", &v, err)
+}
+
+// constraintField is the field of a violated constraint: its column if
+// reported, otherwise the constraint name.
+func constraintField(pgErr *pgconn.PgError) string {
+	if pgErr.ColumnName != "" {
+		return pgErr.ColumnName
+	}
+
+	return pgErr.ConstraintName
+}
@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+func TestWrapPgxError(t *testing.T) {
+	tests := []struct {
+		name           string
+		given          error
+		expectedErr    error // Sentinel the result must match, nil if none.
+		expectedStatus int   // Of the API error, 0 if not an API error.
+		expectedDetail string
+		expectedFields []example.FieldError
+		expectedAlert  bool
+		// Whether the error says the database is unavailable, opening
+		// breakers, rather than temporary.
+		expectedUnavailable bool
+	}{
+		{
+			name:           "no rows",
+			given:          pgx.ErrNoRows,
+			expectedErr:    example.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedDetail: "not found",
+		},
+		{
+			name:                "dial failure",
+			given:               &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+			expectedErr:         example.ErrTemporary,
+			expectedStatus:      http.StatusServiceUnavailable,
+			expectedDetail:      "temporary error",
+			expectedUnavailable: true,
+		},
+		{
+			name:           "unique violation",
+			given:          &pgconn.PgError{Code: pgerrcode.UniqueViolation, ConstraintName: "example_pkey"},
+			expectedErr:    example.ErrConflict,
+			expectedStatus: http.StatusConflict,
+			expectedDetail: "conflicts with an existing resource (example_pkey)",
+		},
+		{
+			name:           "not null violation",
+			given:          &pgconn.PgError{Code: pgerrcode.NotNullViolation, ColumnName: "name"},
+			expectedErr:    example.ErrValidation,
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "invalid request",
+			expectedFields: []example.FieldError{{Field: "name", Message: "is required"}},
+		},
+		{
+			name:           "foreign key violation",
+			given:          &pgconn.PgError{Code: pgerrcode.ForeignKeyViolation, ConstraintName: "webhook_delivery_subscription_id_fkey"},
+			expectedErr:    example.ErrValidation,
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "invalid request",
+			expectedFields: []example.FieldError{{Field: "webhook_delivery_subscription_id_fkey", Message: "references a resource that does not exist"}},
+		},
+		{
+			name:           "check violation",
+			given:          &pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "example_name_check"},
+			expectedErr:    example.ErrValidation,
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "invalid request",
+			expectedFields: []example.FieldError{{Field: "example_name_check", Message: "violates constraint example_name_check"}},
+		},
+		{
+			name:           "serialization failure",
+			given:          &pgconn.PgError{Code: pgerrcode.SerializationFailure},
+			expectedErr:    example.ErrTemporary,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedDetail: "temporary error",
+		},
+		{
+			name:           "deadlock",
+			given:          &pgconn.PgError{Code: pgerrcode.DeadlockDetected},
+			expectedErr:    example.ErrTemporary,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedDetail: "temporary error",
+		},
+		{
+			name:           "query canceled",
+			given:          &pgconn.PgError{Code: pgerrcode.QueryCanceled},
+			expectedErr:    example.ErrTimeout,
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedDetail: "timeout",
+		},
+		{
+			name:           "context deadline",
+			given:          fmt.Errorf("timeout: %w", context.DeadlineExceeded),
+			expectedErr:    example.ErrTimeout,
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedDetail: "timeout",
+		},
+		{
+			name:                "connection exception",
+			given:               &pgconn.PgError{Code: pgerrcode.ConnectionFailure},
+			expectedErr:         example.ErrTemporary,
+			expectedStatus:      http.StatusServiceUnavailable,
+			expectedDetail:      "temporary error",
+			expectedUnavailable: true,
+		},
+		{
+			name:                "too many connections",
+			given:               &pgconn.PgError{Code: pgerrcode.TooManyConnections},
+			expectedErr:         example.ErrTemporary,
+			expectedStatus:      http.StatusServiceUnavailable,
+			expectedDetail:      "temporary error",
+			expectedUnavailable: true,
+		},
+		{
+			name:                "admin shutdown",
+			given:               &pgconn.PgError{Code: pgerrcode.AdminShutdown},
+			expectedErr:         example.ErrTemporary,
+			expectedStatus:      http.StatusServiceUnavailable,
+			expectedDetail:      "temporary error",
+			expectedUnavailable: true,
+		},
+		{
+			name:          "insufficient privilege",
+			given:         &pgconn.PgError{Code: pgerrcode.InsufficientPrivilege},
+			expectedAlert: true,
+		},
+		{
+			name:  "unknown",
+			given: &pgconn.PgError{Code: pgerrcode.DivisionByZero},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := wrapPgxError(tt.given)
+
+			// Assert
+			require.Error(t, err)
+			assert.Equal(t, tt.expectedAlert, example.IsAlert(err))
+			assert.Equal(t, tt.expectedUnavailable, example.IsUnavailable(err))
+
+			var apiErr example.APIError
+			if tt.expectedErr == nil {
+				assert.False(t, errors.As(err, &apiErr), "should be an internal error")
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			require.True(t, errors.As(err, &apiErr))
+
+			status, detail := apiErr.APIError()
+			assert.Equal(t, tt.expectedStatus, status)
+			assert.Equal(t, tt.expectedDetail, detail)
+
+			var validationErr *example.ValidationError
+			if tt.expectedFields != nil {
+				require.True(t, errors.As(err, &validationErr))
+				assert.Equal(t, tt.expectedFields, validationErr.Fields)
+			}
+		})
+	}
+}
+
+func TestWrapPgxErrorKeepsCause(t *testing.T) {
+	// Arrange
+	given := &pgconn.PgError{Code: pgerrcode.SerializationFailure}
+
+	// Act
+	err := wrapPgxError(given)
+
+	// Assert
+	assert.True(t, isRetryable(err), "transactions should still be retried")
+}
@@ -2,9 +2,12 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/bratteby/go-service-template/internal/example"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 )
 
 type ExampleRepository struct {
@@ -13,13 +16,13 @@ type ExampleRepository struct {
 
 func (r *ExampleRepository) FindOneByID(ctx context.Context, id uuid.UUID) (example.Example, error) {
 	query := `
-		SELECT id, name
+		SELECT id, name, created_at, version, owner
 		FROM example
 		WHERE id = $1
 	`
 
 	var ex example.Example
-	if err := r.DB.QueryRow(ctx, query, id).Scan(&ex.ID, &ex.Name); err != nil {
+	if err := r.DB.QueryRow(ctx, query, id).Scan(&ex.ID, &ex.Name, &ex.CreatedAt, &ex.Version, &ex.Owner); err != nil {
 		return example.Example{}, wrapPgxError(err)
 	}
 
@@ -28,15 +31,146 @@ func (r *ExampleRepository) FindOneByID(ctx context.Context, id uuid.UUID) (exam
 
 func (r *ExampleRepository) Save(ctx context.Context, ex example.Example) error {
 	sql := `
-		INSERT INTO example(id, name) values (
-			$1, $2
-		) 
+		INSERT INTO example(id, name, created_at, version, owner) values (
+			$1, $2, $3, $4, $5
+		)
 	`
 
-	_, err := r.DB.Exec(ctx, sql, ex.ID, ex.Name)
+	_, err := r.DB.Exec(ctx, sql, ex.ID, ex.Name, ex.CreatedAt, ex.Version, ex.Owner)
 	if err != nil {
 		return wrapPgxError(err)
 	}
 
 	return nil
 }
+
+// List lists examples using keyset pagination on (created_at, id).
+func (r *ExampleRepository) List(ctx context.Context, q example.ListQuery) ([]example.Example, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if q.Name != "" {
+		pattern := escapeLike(q.Name) + "%"
+		if q.NameMatch == example.NameMatchContains {
+			pattern = "%" + pattern
+		}
+
+		args = append(args, pattern)
+		conditions = append(conditions, fmt.Sprintf("name LIKE $%d", len(args)))
+	}
+
+	direction, comparison := "ASC", ">"
+	if q.Order == example.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if q.After != nil {
+		args = append(args, q.After.CreatedAt, q.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, q.Limit)
+	query := fmt.Sprintf(`
+		SELECT id, name, created_at, version, owner
+		FROM example
+		%s
+		ORDER BY created_at %s, id %s
+		LIMIT $%d
+	`, where, direction, direction, len(args))
+
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapPgxError(err)
+	}
+	defer rows.Close()
+
+	examples := []example.Example{}
+	for rows.Next() {
+		var ex example.Example
+		if err := rows.Scan(&ex.ID, &ex.Name, &ex.CreatedAt, &ex.Version, &ex.Owner); err != nil {
+			return nil, wrapPgxError(err)
+		}
+
+		examples = append(examples, ex)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapPgxError(err)
+	}
+
+	return examples, nil
+}
+
+// Update updates the example if its version matches expectedVersion, the
+// version is incremented on every update.
+func (r *ExampleRepository) Update(ctx context.Context, ex example.Example, expectedVersion *int) (example.Example, error) {
+	query := `
+		UPDATE example
+		SET name = $2, version = version + 1
+		WHERE id = $1 AND ($3::INTEGER IS NULL OR version = $3)
+		RETURNING id, name, created_at, version, owner
+	`
+
+	var updated example.Example
+	err := r.DB.QueryRow(ctx, query, ex.ID, ex.Name, expectedVersion).
+		Scan(&updated.ID, &updated.Name, &updated.CreatedAt, &updated.Version, &updated.Owner)
+	if err == pgx.ErrNoRows {
+		return example.Example{}, r.missingOrConflict(ctx, ex.ID)
+	}
+	if err != nil {
+		return example.Example{}, wrapPgxError(err)
+	}
+
+	return updated, nil
+}
+
+// Delete deletes the example if its version matches expectedVersion.
+func (r *ExampleRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion *int) error {
+	sql := `
+		DELETE FROM example
+		WHERE id = $1 AND ($2::INTEGER IS NULL OR version = $2)
+	`
+
+	tag, err := r.DB.Exec(ctx, sql, id, expectedVersion)
+	if err != nil {
+		return wrapPgxError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return r.missingOrConflict(ctx, id)
+	}
+
+	return nil
+}
+
+// missingOrConflict tells apart why a conditional write affected no rows,
+// either the example does not exist or its version did not match.
+func (r *ExampleRepository) missingOrConflict(ctx context.Context, id uuid.UUID) error {
+	query := `
+		SELECT version
+		FROM example
+		WHERE id = $1
+	`
+
+	var version int
+	if err := r.DB.QueryRow(ctx, query, id).Scan(&version); err != nil {
+		return wrapPgxError(err)
+	}
+
+	return example.WrapError(
+		fmt.Errorf("version mismatch, current version is %d", version),
+		example.ErrConflict,
+	)
+}
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/idempotency"
+)
+
+// IdempotencyRepository stores idempotency keys and the responses to their
+// requests.
+type IdempotencyRepository struct {
+	DB pool
+}
+
+// Reserve inserts r, taking over an expired record with the same key or
+// one without response whose lock passed.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, rec idempotency.Record) (idempotency.Record, bool, error) {
+	sql := `
+		INSERT INTO idempotency_key(scope, key, fingerprint, created_at, expires_at, locked_until) VALUES (
+			$1, $2, $3, $4, $5, $6
+		)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			header = NULL,
+			body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at,
+			locked_until = EXCLUDED.locked_until
+		WHERE idempotency_key.expires_at <= EXCLUDED.created_at
+			OR (idempotency_key.status_code IS NULL AND idempotency_key.locked_until <= EXCLUDED.created_at)
+	`
+
+	tag, err := r.DB.Exec(ctx, sql, rec.Scope, rec.Key, rec.Fingerprint, rec.CreatedAt, rec.ExpiresAt, rec.LockedUntil)
+	if err != nil {
+		return idempotency.Record{}, false, wrapPgxError(err)
+	}
+
+	if tag.RowsAffected() == 1 {
+		return rec, true, nil
+	}
+
+	existing, err := r.find(ctx, rec.Scope, rec.Key)
+	if errors.Is(err, example.ErrNotFound) {
+		// Released or purged since the insert conflicted.
+		return idempotency.Record{}, false, example.WrapError(fmt.Errorf("idempotency key %q vanished: %w", rec.Key, err), example.ErrTemporary)
+	}
+
+	return existing, false, err
+}
+
+func (r *IdempotencyRepository) find(ctx context.Context, scope, key string) (idempotency.Record, error) {
+	query := `
+		SELECT fingerprint, status_code, header, body, created_at, expires_at, locked_until
+		FROM idempotency_key
+		WHERE scope = $1 AND key = $2
+	`
+
+	var (
+		rec        = idempotency.Record{Scope: scope, Key: key}
+		statusCode *int
+		header     []byte
+		body       []byte
+	)
+
+	err := r.DB.QueryRow(ctx, query, scope, key).Scan(
+		&rec.Fingerprint, &statusCode, &header, &body, &rec.CreatedAt, &rec.ExpiresAt, &rec.LockedUntil,
+	)
+	if err != nil {
+		return idempotency.Record{}, wrapPgxError(err)
+	}
+
+	if statusCode != nil {
+		rec.Response = &idempotency.Response{StatusCode: *statusCode, Body: body}
+		if err := json.Unmarshal(header, &rec.Response.Header); err != nil {
+			return idempotency.Record{}, fmt.Errorf("could not decode stored header: %w", err)
+		}
+	}
+
+	return rec, nil
+}
+
+// Complete stores the response of a reserved key. A reservation taken over
+// since has another creation time and is left alone.
+func (r *IdempotencyRepository) Complete(ctx context.Context, rec idempotency.Record, resp idempotency.Response) error {
+	sql := `
+		UPDATE idempotency_key
+		SET status_code = $4, header = $5, body = $6
+		WHERE scope = $1 AND key = $2 AND created_at = $3 AND status_code IS NULL
+	`
+
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return fmt.Errorf("could not encode header: %w", err)
+	}
+
+	if _, err := r.DB.Exec(ctx, sql, rec.Scope, rec.Key, rec.CreatedAt, resp.StatusCode, header, resp.Body); err != nil {
+		return wrapPgxError(err)
+	}
+
+	return nil
+}
+
+// Release deletes a key reserved without response, unless taken over.
+func (r *IdempotencyRepository) Release(ctx context.Context, rec idempotency.Record) error {
+	sql := `
+		DELETE FROM idempotency_key
+		WHERE scope = $1 AND key = $2 AND created_at = $3 AND status_code IS NULL
+	`
+
+	if _, err := r.DB.Exec(ctx, sql, rec.Scope, rec.Key, rec.CreatedAt); err != nil {
+		return wrapPgxError(err)
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes the keys expired at now.
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	sql := `
+		DELETE FROM idempotency_key
+		WHERE expires_at <= $1
+	`
+
+	tag, err := r.DB.Exec(ctx, sql, now)
+	if err != nil {
+		return 0, wrapPgxError(err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres" // Driver of the postgres:// URLs.
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// migrationLockKey is the key of the advisory lock held while checking and
+// migrating the schema. It differs from the lock golang-migrate takes for
+// each migration, which does not cover the check.
+const migrationLockKey int64 = 0x6578616d706c65 // "example"
+
+// LatestMigrationVersion returns the highest version of the migration files
+// in fsys, named <version>_<title>.up.sql.
+func LatestMigrationVersion(fsys fs.FS) (uint, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return 0, fmt.Errorf("could not read migrations: %w", err)
+	}
+
+	var latest uint
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".up.sql") {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(e.Name(), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration version of %s: %w", e.Name(), err)
+		}
+
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+
+	return latest, nil
+}
+
+// SchemaVersion returns the migration version of the database and whether
+// its last migration failed. The version is 0 if no migration was applied.
+func SchemaVersion(ctx context.Context, db pool) (version uint, dirty bool, err error) {
+	query := `
+		SELECT version, dirty
+		FROM schema_migrations
+	`
+
+	var current int64
+	err = db.QueryRow(ctx, query).Scan(&current, &dirty)
+
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, false, nil
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UndefinedTable:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("could not get migration version: %w", err)
+	}
+
+	// golang-migrate stores -1 when forced to no version.
+	if current < 0 {
+		return 0, dirty, nil
+	}
+
+	return uint(current), dirty, nil
+}
+
+// CheckSchema returns an error if the last migration of the database failed
+// or its version is ahead of latest, the version known to the binary.
+// Older schemas pass, they are either migrated on startup or by a separate
+// job and reported by MigrationCheck until then.
+func CheckSchema(ctx context.Context, db pool, latest uint) error {
+	current, dirty, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	return checkVersion(current, dirty, latest)
+}
+
+func checkVersion(current uint, dirty bool, latest uint) error {
+	switch {
+	case dirty:
+		return fmt.Errorf("schema is dirty, migration %d failed and must be fixed and forced", current)
+	case current > latest:
+		return fmt.Errorf("schema version %d is ahead of the latest migration %d of this binary", current, latest)
+	}
+
+	return nil
+}
+
+// Migrate applies the pending migrations of fsys to the database of
+// connString, after checking the schema as CheckSchema. The check and the
+// migrations run under an advisory lock held on a connection of p, so
+// replicas starting at once wait for the first one to migrate instead of
+// racing it. The versions before and after migrating are returned, both are
+// the version before if err is not nil.
+func Migrate(ctx context.Context, p *pgxpool.Pool, connString string, fsys fs.FS) (from, to uint, err error) {
+	latest, err := LatestMigrationVersion(fsys)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	conn, err := p.Acquire(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return 0, 0, fmt.Errorf("could not acquire migration lock: %w", err)
+	}
+
+	defer func() {
+		// ctx may be done, the lock must be released regardless.
+		if _, unlockErr := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); unlockErr != nil && err == nil {
+			err = fmt.Errorf("could not release migration lock: %w", unlockErr)
+		}
+	}()
+
+	from, dirty, err := SchemaVersion(ctx, conn)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := checkVersion(from, dirty, latest); err != nil {
+		return from, from, err
+	}
+
+	if from == latest {
+		return from, from, nil
+	}
+
+	source, err := iofs.New(fsys, ".")
+	if err != nil {
+		return from, from, fmt.Errorf("could not read migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", source, connString)
+	if err != nil {
+		return from, from, fmt.Errorf("could not create migrate instance: %w", err)
+	}
+	defer m.Close()
+
+	// Stop between migrations once ctx is done, a migration in progress is
+	// completed.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.GracefulStop <- true
+		case <-stop:
+		}
+	}()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return from, from, fmt.Errorf("could not migrate from version %d: %w", from, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return from, from, fmt.Errorf("migration stopped: %w", err)
+	}
+
+	return from, latest, nil
+}
+
+// MigrationCheck returns a check failing unless the database has been
+// migrated to version without a failed migration.
+func MigrationCheck(db pool, version uint) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		current, dirty, err := SchemaVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case dirty:
+			return fmt.Errorf("migration %d failed", current)
+		case current != version:
+			return fmt.Errorf("migration version is %d, expected %d", current, version)
+		}
+
+		return nil
+	}
+}
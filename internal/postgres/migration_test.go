@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/migrations"
+)
+
+func TestLatestMigrationVersion(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"0001_initial.up.sql":     {},
+		"0001_initial.down.sql":   {},
+		"0012_later.up.sql":       {},
+		"0012_later.down.sql":     {},
+		"0013_only_down.down.sql": {},
+		"init.sql":                {},
+	}
+
+	// Act
+	latest, err := LatestMigrationVersion(fsys)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, uint(12), latest)
+}
+
+func TestLatestMigrationVersion_Embedded(t *testing.T) {
+	// Act
+	latest, err := LatestMigrationVersion(migrations.FS)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotZero(t, latest)
+
+	_, err = migrations.FS.Open("init.sql")
+	assert.Error(t, err, "init.sql must not be embedded")
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     uint
+		dirty       bool
+		expectedErr string
+	}{
+		{name: "no migrations applied", current: 0},
+		{name: "behind", current: 3},
+		{name: "latest", current: 5},
+		{name: "ahead", current: 6, expectedErr: "schema version 6 is ahead of the latest migration 5 of this binary"},
+		{name: "dirty", current: 4, dirty: true, expectedErr: "schema is dirty, migration 4 failed and must be fixed and forced"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := checkVersion(tt.current, tt.dirty, 5)
+
+			// Assert
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/outbox"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// OutboxChannel is notified with the event id of every event appended to
+// the outbox.
+const OutboxChannel = "outbox"
+
+// OutboxRepository stores domain events in the outbox table until they are
+// published by the outbox relay.
+type OutboxRepository struct {
+	DB pool
+}
+
+// Append stores events, within the transaction of ctx when DB joins it.
+func (r *OutboxRepository) Append(ctx context.Context, events ...example.Event) error {
+	sql := `
+		INSERT INTO outbox(id, type, aggregate_id, payload, occurred_at, owner) values (
+			$1, $2, $3, $4, $5, $6
+		)
+	`
+
+	for _, e := range events {
+		if _, err := r.DB.Exec(ctx, sql, e.ID, e.Type, e.ExampleID, e.Data, e.OccurredAt, e.Owner); err != nil {
+			return wrapPgxError(err)
+		}
+	}
+
+	return nil
+}
+
+// ClaimPending returns up to limit pending events due at now, oldest
+// first, leasing them until leaseUntil. The events are leased in a single
+// statement, it needs no transaction.
+func (r *OutboxRepository) ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]outbox.PendingEvent, error) {
+	query := `
+		UPDATE outbox
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id
+			FROM outbox
+			WHERE published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= $1
+			ORDER BY occurred_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, type, aggregate_id, payload, occurred_at, owner, attempts
+	`
+
+	rows, err := r.DB.Query(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, wrapPgxError(err)
+	}
+	defer rows.Close()
+
+	events := []outbox.PendingEvent{}
+	for rows.Next() {
+		var e outbox.PendingEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.ExampleID, &e.Data, &e.OccurredAt, &e.Owner, &e.Attempts); err != nil {
+			return nil, wrapPgxError(err)
+		}
+
+		e.OccurredAt = e.OccurredAt.UTC()
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapPgxError(err)
+	}
+
+	// RETURNING does not keep the order of the claim.
+	sort.SliceStable(events, func(i, j int) bool { return events[i].OccurredAt.Before(events[j].OccurredAt) })
+
+	return events, nil
+}
+
+// MarkPublished marks the event as published.
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id uuid.UUID, at time.Time) error {
+	sql := `
+		UPDATE outbox
+		SET published_at = $2, attempts = attempts + 1, last_error = NULL
+		WHERE id = $1
+	`
+
+	if _, err := r.DB.Exec(ctx, sql, id, at); err != nil {
+		return wrapPgxError(err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed publish attempt, the event stays pending
+// until retryAt.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, cause error, retryAt time.Time) error {
+	sql := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $1
+	`
+
+	if _, err := r.DB.Exec(ctx, sql, id, cause.Error(), retryAt); err != nil {
+		return wrapPgxError(err)
+	}
+
+	return nil
+}
+
+// MarkDead records the last failed publish attempt, the event is no longer
+// claimed.
+func (r *OutboxRepository) MarkDead(ctx context.Context, id uuid.UUID, cause error, at time.Time) error {
+	sql := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2, dead_at = $3
+		WHERE id = $1
+	`
+
+	if _, err := r.DB.Exec(ctx, sql, id, cause.Error(), at); err != nil {
+		return wrapPgxError(err)
+	}
+
+	return nil
+}
+
+// Listen sends on notify for every notification on channel, dropping
+// notifications while one is pending. It holds a connection of p until ctx
+// is done or the connection fails, in which case the error is returned.
+func Listen(ctx context.Context, p *pgxpool.Pool, channel string, notify chan<- struct{}) error {
+	conn, err := p.Acquire(ctx)
+	if err != nil {
+		return wrapPgxError(fmt.Errorf("could not acquire connection: %w", err))
+	}
+	defer func() {
+		// Closed rather than returned to the pool, so it does not keep
+		// listening nor is reused in an unknown state.
+		conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return wrapPgxError(fmt.Errorf("could not listen on %s: %w", channel, err))
+	}
+
+	for {
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return wrapPgxError(fmt.Errorf("could not wait for notification: %w", err))
+		}
+
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"net/url"
 
 	"github.com/jackc/pgtype/pgxtype"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -21,14 +22,23 @@ type ConnectionConfig struct {
 	SSL      string
 }
 
+// ConnString returns the connection URL of the config, user and password are
+// escaped so they may contain any character.
+func (c ConnectionConfig) ConnString() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     fmt.Sprintf("%s:%s", c.Host, c.Port),
+		Path:     c.DB,
+		RawQuery: url.Values{"sslmode": []string{c.SSL}}.Encode(),
+	}
+
+	return u.String()
+}
+
 // NewPool initializes a new postgres connection pool.
 func NewPool(c ConnectionConfig) (*pgxpool.Pool, error) {
-	postgresConn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		c.User, c.Password, c.Host, c.Port, c.DB, c.SSL,
-	)
-
-	pgxConfig, err := pgxpool.ParseConfig(postgresConn)
+	pgxConfig, err := pgxpool.ParseConfig(c.ConnString())
 	if err != nil {
 		return nil, fmt.Errorf("error parsing postgres config %w", err)
 	}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/bratteby/go-service-template/internal/ratelimit"
+)
+
+// RateLimitRepository stores rate limit buckets shared by all replicas. A
+// bucket is its theoretical arrival time in unix nanoseconds, see
+// ratelimit.Evaluate.
+type RateLimitRepository struct {
+	DB pool
+}
+
+// Take takes a request from the bucket of key in a single statement, so
+// concurrent requests of replicas are serialized by the row lock.
+func (r *RateLimitRepository) Take(ctx context.Context, key string, l ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	sql := `
+		INSERT INTO rate_limit AS rl (key, tat) VALUES ($1, $2::BIGINT + $3)
+		ON CONFLICT (key) DO UPDATE SET tat = GREATEST(rl.tat, $2) + $3
+		WHERE GREATEST(rl.tat, $2) + $3 - $2 <= $4
+		RETURNING tat
+	`
+
+	interval := l.Interval()
+
+	var tat int64
+	err := r.DB.QueryRow(ctx, sql, key, now.UnixNano(), int64(interval), int64(l.Window)).Scan(&tat)
+	if err == nil {
+		// tat is the updated one, evaluating the request from the tat
+		// before it yields the same result.
+		res, _ := ratelimit.Evaluate(l, now, time.Unix(0, tat).Add(-interval))
+		return res, nil
+	}
+	if err != pgx.ErrNoRows {
+		return ratelimit.Result{}, wrapPgxError(err)
+	}
+
+	// Denied, the bucket is left as is.
+	query := `
+		SELECT tat FROM rate_limit WHERE key = $1
+	`
+
+	if err := r.DB.QueryRow(ctx, query, key).Scan(&tat); err != nil {
+		return ratelimit.Result{}, wrapPgxError(err)
+	}
+
+	res, _ := ratelimit.Evaluate(l, now, time.Unix(0, tat))
+	return res, nil
+}
+
+// DeleteExpired deletes the buckets full at now, which are the same as
+// missing ones.
+func (r *RateLimitRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	sql := `
+		DELETE FROM rate_limit
+		WHERE tat <= $1
+	`
+
+	tag, err := r.DB.Exec(ctx, sql, now.UnixNano())
+	if err != nil {
+		return 0, wrapPgxError(err)
+	}
+
+	return tag.RowsAffected(), nil
+}
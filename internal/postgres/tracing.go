@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/bratteby/go-service-template/internal/postgres")
+
+// TracedDB wraps a pool to record a client span for every statement, with
+// the statement text but not its arguments.
+type TracedDB struct {
+	DB pool
+}
+
+func (t TracedDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	ctx, span := startStatementSpan(ctx, sql)
+	defer span.End()
+
+	tag, err := t.DB.Exec(ctx, sql, args...)
+	recordStatementError(span, err)
+
+	return tag, err
+}
+
+func (t TracedDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, span := startStatementSpan(ctx, sql)
+
+	rows, err := t.DB.Query(ctx, sql, args...)
+	if err != nil {
+		recordStatementError(span, err)
+		span.End()
+		return nil, err
+	}
+
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (t TracedDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, span := startStatementSpan(ctx, sql)
+
+	return &tracedRow{Row: t.DB.QueryRow(ctx, sql, args...), span: span}
+}
+
+// tracedRows ends the span of a query once its rows are closed.
+type tracedRows struct {
+	pgx.Rows
+	span trace.Span
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+
+	recordStatementError(r.span, r.Rows.Err())
+	r.span.End()
+}
+
+// tracedRow ends the span of a single row query once it is scanned, as the
+// statement is only executed then.
+type tracedRow struct {
+	pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...any) error {
+	err := r.Row.Scan(dest...)
+
+	recordStatementError(r.span, err)
+	r.span.End()
+
+	return err
+}
+
+func startStatementSpan(ctx context.Context, sql string) (context.Context, trace.Span) {
+	statement := strings.Join(strings.Fields(sql), " ")
+
+	operation, _, _ := strings.Cut(statement, " ")
+	operation = strings.ToUpper(operation)
+
+	return tracer.Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationKey.String(operation),
+			semconv.DBStatementKey.String(statement),
+		),
+	)
+}
+
+// recordStatementError records err on span, no rows is an expected outcome
+// and not recorded.
+func recordStatementError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, pgx.ErrNoRows) {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
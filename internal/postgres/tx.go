@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type txPool interface {
+	pool
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+type txKey struct{}
+
+// TxManager runs units of work in transactions. It is also the pool of the
+// repositories: statements executed with the context passed to WithTx join
+// its transaction, other statements run directly on DB.
+type TxManager struct {
+	DB txPool
+	// IsoLevel of the transactions, the server default if empty.
+	IsoLevel pgx.TxIsoLevel
+	// MaxRetries of transactions failing with a serialization failure or
+	// deadlock.
+	MaxRetries int
+}
+
+// WithTx runs fn in a transaction that is committed if fn returns nil and
+// rolled back otherwise. fn is retried in a new transaction on serialization
+// failures and deadlocks, so it must not have side effects outside of the
+// database. Nested calls join the outer transaction.
+func (m *TxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := tracer.Start(ctx, "postgres transaction", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, fn)
+		if err == nil {
+			span.SetAttributes(attribute.Int("db.transaction.retries", attempt))
+			return nil
+		}
+
+		if !isRetryable(err) || attempt >= m.MaxRetries {
+			span.SetAttributes(attribute.Int("db.transaction.retries", attempt))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+
+		// Back off a little so the competing transaction can finish.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * 10 * time.Millisecond):
+		}
+	}
+}
+
+// run runs a single attempt of fn.
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: m.IsoLevel})
+	if err != nil {
+		return wrapPgxError(fmt.Errorf("could not begin transaction: %w", err))
+	}
+
+	defer func() {
+		// Rollback is a no-op after commit, an error would only hide err.
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrapPgxError(fmt.Errorf("could not commit transaction: %w", err))
+	}
+
+	return nil
+}
+
+// InTx reports whether ctx carries a transaction of a TxManager.
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return ok
+}
+
+// conn returns the transaction of ctx, if any, otherwise DB.
+func (m *TxManager) conn(ctx context.Context) pool {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return m.DB
+}
+
+func (m *TxManager) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return m.conn(ctx).Exec(ctx, sql, args...)
+}
+
+func (m *TxManager) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return m.conn(ctx).Query(ctx, sql, args...)
+}
+
+func (m *TxManager) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return m.conn(ctx).QueryRow(ctx, sql, args...)
+}
+
+// isRetryable reports whether err is a serialization failure or deadlock,
+// after which the transaction may succeed when retried.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgerrcode.SerializationFailure || pgErr.Code == pgerrcode.DeadlockDetected
+}
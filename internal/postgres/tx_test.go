@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx records how a transaction ended, other methods are not supported.
+type fakeTx struct {
+	pgx.Tx
+	committed, rolledBack bool
+	commitErr             error
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	t.committed = true
+	return t.commitErr
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	t.rolledBack = true
+	return nil
+}
+
+// fakeTxPool hands out fakeTxs, commits fail with the given errors in turn.
+type fakeTxPool struct {
+	pool
+	commitErrs []error
+	txs        []*fakeTx
+}
+
+func (p *fakeTxPool) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	tx := &fakeTx{}
+	if len(p.commitErrs) > 0 {
+		tx.commitErr, p.commitErrs = p.commitErrs[0], p.commitErrs[1:]
+	}
+
+	p.txs = append(p.txs, tx)
+	return tx, nil
+}
+
+func TestWithTx(t *testing.T) {
+	var (
+		serialization = &pgconn.PgError{Code: pgerrcode.SerializationFailure}
+		deadlock      = &pgconn.PgError{Code: pgerrcode.DeadlockDetected}
+		uniqueness    = &pgconn.PgError{Code: pgerrcode.UniqueViolation}
+	)
+
+	tests := []struct {
+		name            string
+		givenErrs       []error // Returned by fn in turn.
+		givenCommitErrs []error
+		expectedErr     error
+		expectedCalls   int
+	}{
+		{
+			name:          "should commit when fn succeeds",
+			expectedCalls: 1,
+		},
+		{
+			name:          "should roll back without retry on other errors",
+			givenErrs:     []error{uniqueness},
+			expectedErr:   uniqueness,
+			expectedCalls: 1,
+		},
+		{
+			name:          "should retry on serialization failure and deadlock",
+			givenErrs:     []error{serialization, deadlock},
+			expectedCalls: 3,
+		},
+		{
+			name:            "should retry on serialization failure on commit",
+			givenCommitErrs: []error{serialization},
+			expectedCalls:   2,
+		},
+		{
+			name:          "should give up after max retries",
+			givenErrs:     []error{serialization, serialization, serialization},
+			expectedErr:   serialization,
+			expectedCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db := &fakeTxPool{commitErrs: tt.givenCommitErrs}
+			m := &TxManager{DB: db, MaxRetries: 2}
+
+			calls := 0
+			fn := func(ctx context.Context) error {
+				calls++
+
+				_, inTx := m.conn(ctx).(*fakeTx)
+				require.True(t, inTx, "should run fn in transaction")
+
+				if calls <= len(tt.givenErrs) {
+					return tt.givenErrs[calls-1]
+				}
+
+				return nil
+			}
+
+			// Act
+			err := m.WithTx(context.Background(), fn)
+
+			// Assert
+			assert.Equal(t, tt.expectedCalls, calls)
+			require.Len(t, db.txs, tt.expectedCalls)
+
+			last := db.txs[len(db.txs)-1]
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.True(t, last.rolledBack)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, last.committed)
+			assert.False(t, last.rolledBack)
+		})
+	}
+}
+
+func TestWithTxNested(t *testing.T) {
+	// Arrange
+	db := &fakeTxPool{}
+	m := &TxManager{DB: db}
+
+	// Act
+	err := m.WithTx(context.Background(), func(ctx context.Context) error {
+		return m.WithTx(ctx, func(ctx context.Context) error {
+			return nil
+		})
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, db.txs, 1, "should join outer transaction")
+	assert.Equal(t, db, m.conn(context.Background()), "should use pool outside of transaction")
+}
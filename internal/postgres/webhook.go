@@ -0,0 +1,282 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/webhook"
+)
+
+// WebhookRepository stores webhook subscriptions and their delivery queue.
+type WebhookRepository struct {
+	DB pool
+}
+
+func (r *WebhookRepository) SaveSubscription(ctx context.Context, s webhook.Subscription) error {
+	sql := `
+		INSERT INTO webhook_subscription(id, url, event_types, secret, owner, all_owners, created_at) values (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+	`
+
+	_, err := r.DB.Exec(ctx, sql, s.ID, s.URL, eventTypeStrings(s.EventTypes), s.Secret, s.Owner, s.AllOwners, s.CreatedAt)
+	if err != nil {
+		return wrapPgxError(err)
+	}
+
+	return nil
+}
+
+// FindSubscription returns the subscription without its secret.
+func (r *WebhookRepository) FindSubscription(ctx context.Context, id uuid.UUID) (webhook.Subscription, error) {
+	query := `
+		SELECT id, url, event_types, owner, all_owners, created_at
+		FROM webhook_subscription
+		WHERE id = $1
+	`
+
+	s, err := scanSubscription(r.DB.QueryRow(ctx, query, id))
+	if err != nil {
+		return webhook.Subscription{}, wrapPgxError(err)
+	}
+
+	return s, nil
+}
+
+// ListSubscriptions returns the subscriptions of owner, or all if empty,
+// without their secrets.
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context, owner string) ([]webhook.Subscription, error) {
+	query := `
+		SELECT id, url, event_types, owner, all_owners, created_at
+		FROM webhook_subscription
+		WHERE $1 = '' OR owner = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.DB.Query(ctx, query, owner)
+	if err != nil {
+		return nil, wrapPgxError(err)
+	}
+	defer rows.Close()
+
+	subs := []webhook.Subscription{}
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, wrapPgxError(err)
+		}
+
+		subs = append(subs, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapPgxError(err)
+	}
+
+	return subs, nil
+}
+
+// DeleteSubscription deletes the subscription with its deliveries.
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM webhook_subscription WHERE id = $1`, id)
+	if err != nil {
+		return wrapPgxError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return example.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]webhook.Delivery, error) {
+	query := `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at
+		FROM webhook_delivery
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.DB.Query(ctx, query, subscriptionID, limit)
+	if err != nil {
+		return nil, wrapPgxError(err)
+	}
+	defer rows.Close()
+
+	deliveries := []webhook.Delivery{}
+	for rows.Next() {
+		var d webhook.Delivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt); err != nil {
+			return nil, wrapPgxError(err)
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapPgxError(err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) ListAttempts(ctx context.Context, subscriptionID, deliveryID uuid.UUID) ([]webhook.Attempt, error) {
+	query := `
+		SELECT a.id, a.delivery_id, a.attempted_at, a.status_code, a.error, a.duration_ms
+		FROM webhook_attempt a
+		JOIN webhook_delivery d ON d.id = a.delivery_id
+		WHERE d.subscription_id = $1 AND d.id = $2
+		ORDER BY a.attempted_at, a.id
+	`
+
+	rows, err := r.DB.Query(ctx, query, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, wrapPgxError(err)
+	}
+	defer rows.Close()
+
+	attempts := []webhook.Attempt{}
+	for rows.Next() {
+		var a webhook.Attempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.AttemptedAt, &a.StatusCode, &a.Error, &a.DurationMS); err != nil {
+			return nil, wrapPgxError(err)
+		}
+
+		attempts = append(attempts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapPgxError(err)
+	}
+
+	return attempts, nil
+}
+
+// EnqueueDeliveries queues e for every subscription of its type owned by
+// the owner of e, or subscribed to all owners. Events already queued for a
+// subscription are ignored.
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, e example.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("could not encode event: %w", err)
+	}
+
+	sql := `
+		INSERT INTO webhook_delivery(id, subscription_id, event_id, event_type, payload, next_attempt_at, created_at)
+		SELECT gen_random_uuid(), id, $1, $2, $3, now(), now()
+		FROM webhook_subscription
+		WHERE (event_types = '{}' OR $2 = ANY(event_types))
+			AND (all_owners OR ($4 <> '' AND owner = $4))
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+
+	if _, err := r.DB.Exec(ctx, sql, e.ID, string(e.Type), payload, e.Owner); err != nil {
+		return wrapPgxError(err)
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries leases the deliveries in a single statement, it needs
+// no transaction.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]webhook.PendingDelivery, error) {
+	query := `
+		UPDATE webhook_delivery d
+		SET next_attempt_at = $2
+		FROM webhook_subscription s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT id
+			FROM webhook_delivery
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at, d.created_at, s.url, s.secret
+	`
+
+	rows, err := r.DB.Query(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, wrapPgxError(err)
+	}
+	defer rows.Close()
+
+	deliveries := []webhook.PendingDelivery{}
+	for rows.Next() {
+		var d webhook.PendingDelivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt, &d.URL, &d.Secret); err != nil {
+			return nil, wrapPgxError(err)
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapPgxError(err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, d webhook.Delivery) error {
+	sql := `
+		UPDATE webhook_delivery
+		SET status = $2, attempts = $3, next_attempt_at = $4
+		WHERE id = $1
+	`
+
+	if _, err := r.DB.Exec(ctx, sql, d.ID, string(d.Status), d.Attempts, d.NextAttemptAt); err != nil {
+		return wrapPgxError(err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) SaveAttempt(ctx context.Context, a webhook.Attempt) error {
+	sql := `
+		INSERT INTO webhook_attempt(id, delivery_id, attempted_at, status_code, error, duration_ms) values (
+			$1, $2, $3, $4, $5, $6
+		)
+	`
+
+	if _, err := r.DB.Exec(ctx, sql, a.ID, a.DeliveryID, a.AttemptedAt, a.StatusCode, a.Error, a.DurationMS); err != nil {
+		return wrapPgxError(err)
+	}
+
+	return nil
+}
+
+func scanSubscription(row pgx.Row) (webhook.Subscription, error) {
+	var (
+		s     webhook.Subscription
+		types []string
+	)
+
+	if err := row.Scan(&s.ID, &s.URL, &types, &s.Owner, &s.AllOwners, &s.CreatedAt); err != nil {
+		return webhook.Subscription{}, err
+	}
+
+	s.EventTypes = make([]example.EventType, len(types))
+	for i, t := range types {
+		s.EventTypes[i] = example.EventType(t)
+	}
+
+	return s, nil
+}
+
+func eventTypeStrings(types []example.EventType) []string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = string(t)
+	}
+
+	return s
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/webhook"
+)
+
+func TestEnqueueDeliveriesOwners(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	conn := testConn(t)
+
+	// Temporary tables shadow the tables of the schema, if migrated, and
+	// are dropped with the connection.
+	_, err := conn.Exec(ctx, `
+		CREATE TEMPORARY TABLE webhook_subscription (
+			id UUID PRIMARY KEY,
+			url TEXT NOT NULL,
+			event_types TEXT[] NOT NULL DEFAULT '{}',
+			secret TEXT NOT NULL,
+			owner TEXT NOT NULL,
+			all_owners BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE TEMPORARY TABLE webhook_delivery (
+			id UUID PRIMARY KEY,
+			subscription_id UUID NOT NULL,
+			event_id UUID NOT NULL,
+			event_type TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			UNIQUE (subscription_id, event_id)
+		);
+	`)
+	require.NoError(t, err)
+
+	repo := &WebhookRepository{DB: conn}
+
+	subscription := func(owner string, allOwners bool) webhook.Subscription {
+		s := webhook.Subscription{
+			ID:         uuid.New(),
+			URL:        "https://example.com/hook",
+			EventTypes: []example.EventType{},
+			Secret:     "secret",
+			Owner:      owner,
+			AllOwners:  allOwners,
+			CreatedAt:  time.Now().UTC(),
+		}
+		require.NoError(t, repo.SaveSubscription(ctx, s))
+
+		return s
+	}
+
+	alice := subscription("alice", false)
+	bob := subscription("bob", false)
+	admin := subscription("admin", true)
+
+	tests := []struct {
+		name     string
+		owner    string
+		expected []uuid.UUID
+	}{
+		{name: "example of alice", owner: "alice", expected: []uuid.UUID{alice.ID, admin.ID}},
+		{name: "example of bob", owner: "bob", expected: []uuid.UUID{bob.ID, admin.ID}},
+		{name: "example without owner", owner: "", expected: []uuid.UUID{admin.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := example.Event{
+				ID:         uuid.New(),
+				Type:       example.EventExampleCreated,
+				ExampleID:  uuid.New(),
+				OccurredAt: time.Now().UTC(),
+				Data:       []byte(`{}`),
+				Owner:      tt.owner,
+			}
+
+			// Act
+			err := repo.EnqueueDeliveries(ctx, event)
+
+			// Assert
+			require.NoError(t, err)
+
+			rows, err := conn.Query(ctx, `SELECT subscription_id FROM webhook_delivery WHERE event_id = $1`, event.ID)
+			require.NoError(t, err)
+			defer rows.Close()
+
+			var subscribers []uuid.UUID
+			for rows.Next() {
+				var id uuid.UUID
+				require.NoError(t, rows.Scan(&id))
+				subscribers = append(subscribers, id)
+			}
+			require.NoError(t, rows.Err())
+
+			assert.ElementsMatch(t, tt.expected, subscribers)
+		})
+	}
+}
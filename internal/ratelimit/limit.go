@@ -0,0 +1,140 @@
+// Package ratelimit limits the rate of requests per client with a token
+// bucket, implemented as the generic cell rate algorithm (GCRA) so a bucket
+// is a single timestamp that is cheap to store and update atomically.
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Window, in bursts of up to Requests.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// ParseLimit parses "<requests>/<window>", e.g. "100/1m". The window is a
+// time.Duration, the count may be left out for a single unit, e.g. "10/s".
+func ParseLimit(s string) (Limit, error) {
+	requests, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%q must be formatted as requests/window", s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("%q: requests must be a positive integer", s)
+	}
+
+	window = strings.TrimSpace(window)
+	if window != "" && strings.IndexAny(window[:1], "0123456789") < 0 {
+		window = "1" + window
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("%q: window must be a positive duration", s)
+	}
+
+	return Limit{Requests: n, Window: d}, nil
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// Interval is the time a single request takes from the bucket.
+func (l Limit) Interval() time.Duration {
+	return l.Window / time.Duration(l.Requests)
+}
+
+// Result is the outcome of taking a request from a bucket.
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// Reset is the duration until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the duration until a denied request would be allowed.
+	RetryAfter time.Duration
+}
+
+// Evaluate takes a request at now from the bucket with theoretical arrival
+// time tat, the time at which the bucket is full again. It returns the
+// result and the new tat, which is tat itself if the request is denied.
+//
+// A bucket that was never used or is full has a tat at or before now.
+func Evaluate(l Limit, now, tat time.Time) (Result, time.Time) {
+	if tat.Before(now) {
+		tat = now
+	}
+
+	interval := l.Interval()
+	next := tat.Add(interval)
+
+	if next.Sub(now) > l.Window {
+		return Result{
+			Limit:      l,
+			Reset:      tat.Sub(now),
+			RetryAfter: next.Sub(now) - l.Window,
+		}, tat
+	}
+
+	return Result{
+		Allowed:   true,
+		Limit:     l,
+		Remaining: int((l.Window - next.Sub(now)) / interval),
+		Reset:     next.Sub(now),
+	}, next
+}
+
+// Rules are the limits of routes, identified by "METHOD pattern" with the
+// chi route pattern, e.g. "POST /api/example". Routes without a rule share
+// the Default limit.
+type Rules struct {
+	Default Limit
+	Routes  map[string]Limit
+	// IP limits all requests per IP address before authentication, see
+	// IPMiddleware. The zero value disables it.
+	IP Limit
+}
+
+// ParseRules parses the default limit and "METHOD pattern=limit" route
+// entries.
+func ParseRules(def string, routes []string) (Rules, error) {
+	d, err := ParseLimit(def)
+	if err != nil {
+		return Rules{}, fmt.Errorf("default: %w", err)
+	}
+
+	rules := Rules{Default: d, Routes: map[string]Limit{}}
+	for _, entry := range routes {
+		route, limit, ok := strings.Cut(entry, "=")
+		method, pattern, hasPattern := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPattern {
+			return Rules{}, fmt.Errorf("%q must be formatted as METHOD pattern=limit", entry)
+		}
+
+		l, err := ParseLimit(limit)
+		if err != nil {
+			return Rules{}, err
+		}
+
+		rules.Routes[strings.ToUpper(method)+" "+strings.TrimSpace(pattern)] = l
+	}
+
+	return rules, nil
+}
+
+// For returns the rule and limit of a route.
+func (r Rules) For(method, pattern string) (string, Limit) {
+	rule := method + " " + pattern
+	if l, ok := r.Routes[rule]; ok {
+		return rule, l
+	}
+
+	return "default", r.Default
+}
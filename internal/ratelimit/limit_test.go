@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		given    string
+		expected Limit
+		err      bool
+	}{
+		{given: "100/1m", expected: Limit{Requests: 100, Window: time.Minute}},
+		{given: "10/s", expected: Limit{Requests: 10, Window: time.Second}},
+		{given: " 5 / 90s ", expected: Limit{Requests: 5, Window: 90 * time.Second}},
+		{given: "100", err: true},
+		{given: "0/1m", err: true},
+		{given: "10/fortnight", err: true},
+		{given: "10/-1m", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.given, func(t *testing.T) {
+			// Act
+			l, err := ParseLimit(tt.given)
+
+			// Assert
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, l)
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	// Arrange
+	rules, err := ParseRules("100/1m", []string{"post /api/example=10/1m", "GET /api/example/{id}=50/1m"})
+	require.NoError(t, err)
+
+	// Act & Assert
+	rule, l := rules.For("POST", "/api/example")
+	assert.Equal(t, "POST /api/example", rule)
+	assert.Equal(t, Limit{Requests: 10, Window: time.Minute}, l)
+
+	rule, l = rules.For("GET", "/api/example/{id}")
+	assert.Equal(t, "GET /api/example/{id}", rule)
+	assert.Equal(t, Limit{Requests: 50, Window: time.Minute}, l)
+
+	rule, l = rules.For("GET", "/api/example")
+	assert.Equal(t, "default", rule)
+	assert.Equal(t, Limit{Requests: 100, Window: time.Minute}, l)
+
+	_, err = ParseRules("100/1m", []string{"/api/example=10/1m"})
+	assert.Error(t, err, "should require a method")
+}
+
+func TestEvaluate(t *testing.T) {
+	// Arrange
+	var (
+		l   = Limit{Requests: 3, Window: 3 * time.Second}
+		now = time.Unix(1700000000, 0)
+		tat time.Time
+		res Result
+	)
+
+	// Act & Assert
+	for i, remaining := range []int{2, 1, 0} {
+		res, tat = Evaluate(l, now, tat)
+		assert.True(t, res.Allowed, "request %d should be allowed in burst", i)
+		assert.Equal(t, remaining, res.Remaining)
+	}
+	assert.Equal(t, 3*time.Second, res.Reset)
+
+	res, denied := Evaluate(l, now, tat)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, tat, denied, "denied request should not take from bucket")
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	res, _ = Evaluate(l, now.Add(time.Second), tat)
+	assert.True(t, res.Allowed, "should be allowed after retry after")
+	assert.Equal(t, 0, res.Remaining)
+
+	res, _ = Evaluate(l, now.Add(time.Hour), tat)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Remaining, "bucket should refill to full")
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is the minimum interval between prunes of full buckets.
+const pruneInterval = time.Minute
+
+// MemoryStore keeps buckets in memory, limits are enforced per replica.
+type MemoryStore struct {
+	mu         sync.Mutex
+	tats       map[string]time.Time
+	lastPruned time.Time
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tats == nil {
+		s.tats = map[string]time.Time{}
+	}
+
+	s.prune(now)
+
+	res, tat := Evaluate(l, now, s.tats[key])
+	s.tats[key] = tat
+
+	return res, nil
+}
+
+// prune drops full buckets, which are the same as missing ones.
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPruned) < pruneInterval {
+		return
+	}
+
+	for key, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, key)
+		}
+	}
+
+	s.lastPruned = now
+}
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+// ErrLimited is returned when a client exceeds its limit.
+var ErrLimited = errors.New("rate limit exceeded")
+
+// Response headers, see the IETF RateLimit header fields draft.
+const (
+	LimitHeader      = "RateLimit-Limit"
+	RemainingHeader  = "RateLimit-Remaining"
+	ResetHeader      = "RateLimit-Reset"
+	PolicyHeader     = "RateLimit-Policy"
+	RetryAfterHeader = "Retry-After"
+)
+
+// Store stores buckets.
+type Store interface {
+	// Take takes a request at now from the bucket of key.
+	Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error)
+}
+
+// ErrorHandler writes the response of a failed request.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware limits requests by the rule of their route per client. Clients
+// are identified by their authenticated principal, or else by their IP
+// address, so it should run after authentication, see IPMiddleware for
+// requests failing it. Limited requests get onError called with ErrLimited.
+//
+// The route pattern is only known once routed, so it must be attached to
+// the routes, e.g. through chi.Router.With, rather than a router.
+//
+// Requests are allowed when the store fails, so an unavailable store
+// doesn't take the API down with it.
+func Middleware(store Store, rules Rules, logger *logging.Logger, onError ErrorHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			pattern := r.URL.Path
+			if rctx := chi.RouteContext(ctx); rctx != nil {
+				pattern = rctx.RoutePattern()
+			}
+
+			rule, limit := rules.For(r.Method, pattern)
+
+			if take(w, r, store, clientKey(r)+" "+rule, rule, limit, logger, onError) {
+				next.ServeHTTP(w, r)
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// IPMiddleware limits all requests per IP address to the IP limit of rules,
+// whatever their route. It runs before authentication, so clients failing
+// to authenticate are limited too, and should be generous as clients
+// behind a NAT share an address.
+func IPMiddleware(store Store, rules Rules, logger *logging.Logger, onError ErrorHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if take(w, r, store, ipKey(r)+" ip", "ip", rules.IP, logger, onError) {
+				next.ServeHTTP(w, r)
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// take takes r from the bucket of key limited by limit of rule, setting the
+// rate limit headers. It reports whether r is allowed, otherwise onError
+// has responded.
+func take(w http.ResponseWriter, r *http.Request, store Store, key, rule string, limit Limit, logger *logging.Logger, onError ErrorHandler) bool {
+	ctx := r.Context()
+
+	res, err := store.Take(ctx, key, limit, time.Now())
+	if err != nil {
+		logger.ErrorCtx(ctx, fmt.Errorf("could not take from rate limit bucket, allowing request: %w", err))
+		return true
+	}
+
+	h := w.Header()
+	h.Set(LimitHeader, strconv.Itoa(limit.Requests))
+	h.Set(RemainingHeader, strconv.Itoa(res.Remaining))
+	h.Set(ResetHeader, seconds(res.Reset))
+	h.Set(PolicyHeader, fmt.Sprintf("%d;w=%s", limit.Requests, seconds(limit.Window)))
+
+	if !res.Allowed {
+		h.Set(RetryAfterHeader, seconds(res.RetryAfter))
+		onError(w, r, fmt.Errorf("%s of %s exceeded: %w", limit, rule, ErrLimited))
+		return false
+	}
+
+	return true
+}
+
+// clientKey identifies the client of a request.
+func clientKey(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return p.ID()
+	}
+
+	return ipKey(r)
+}
+
+// ipKey identifies the IP address of the client of a request.
+func ipKey(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return "ip:" + ip
+}
+
+// seconds formats d as whole seconds, rounded up so clients waiting that
+// long aren't limited again.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+type storeFunc func(ctx context.Context, key string, l Limit, now time.Time) (Result, error)
+
+func (f storeFunc) Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error) {
+	return f(ctx, key, l, now)
+}
+
+func TestMiddleware(t *testing.T) {
+	// Arrange
+	rules, _ := ParseRules("100/1m", []string{"POST /api/example=2/1m"})
+
+	var gotErr error
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	limit := Middleware(&MemoryStore{}, rules, logging.New(io.Discard, logging.Config{}), onError)
+
+	r := chi.NewRouter()
+	r.With(limit).Post("/api/example", func(w http.ResponseWriter, r *http.Request) {})
+	r.With(limit).Get("/api/example/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	send := func(method, path, subject string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if subject != "" {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: subject, Method: auth.MethodAPIKey}))
+		}
+
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+		return res
+	}
+
+	// Act
+	send(http.MethodPost, "/api/example", "alice")
+	send(http.MethodPost, "/api/example", "alice")
+	limited := send(http.MethodPost, "/api/example", "alice")
+	other := send(http.MethodPost, "/api/example", "bob")
+	otherRoute := send(http.MethodGet, "/api/example/1", "alice")
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.ErrorIs(t, gotErr, ErrLimited)
+	assert.Equal(t, "2", limited.Header().Get(LimitHeader))
+	assert.Equal(t, "0", limited.Header().Get(RemainingHeader))
+	assert.Equal(t, "30", limited.Header().Get(RetryAfterHeader))
+	assert.Equal(t, "2;w=60", limited.Header().Get(PolicyHeader))
+
+	assert.Equal(t, http.StatusOK, other.Code, "clients should have their own buckets")
+	assert.Equal(t, "1", other.Header().Get(RemainingHeader))
+
+	assert.Equal(t, http.StatusOK, otherRoute.Code, "routes should have their own rules")
+	assert.Equal(t, "100", otherRoute.Header().Get(LimitHeader))
+	assert.Empty(t, otherRoute.Header().Get(RetryAfterHeader))
+}
+
+func TestMiddlewareAllowsOnStoreFailure(t *testing.T) {
+	// Arrange
+	store := storeFunc(func(ctx context.Context, key string, l Limit, now time.Time) (Result, error) {
+		return Result{}, errors.New("connection refused")
+	})
+
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	var called bool
+	handler := Middleware(store, Rules{Default: Limit{Requests: 1, Window: time.Second}}, logging.New(io.Discard, logging.Config{}), onError)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }),
+	)
+
+	// Act
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// Assert
+	assert.True(t, called)
+}
+
+func TestIPMiddleware(t *testing.T) {
+	// Arrange
+	rules := Rules{Default: Limit{Requests: 100, Window: time.Minute}, IP: Limit{Requests: 2, Window: time.Minute}}
+
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	handler := IPMiddleware(&MemoryStore{}, rules, logging.New(io.Discard, logging.Config{}), onError)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}),
+	)
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/example", nil)
+		req.RemoteAddr = remoteAddr
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	// Act
+	send("192.0.2.1:1234")
+	send("192.0.2.1:1235")
+	limited := send("192.0.2.1:1236")
+	other := send("192.0.2.2:1234")
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code, "ports of an address should share its bucket")
+	assert.Equal(t, "2", limited.Header().Get(LimitHeader))
+	assert.Equal(t, http.StatusUnauthorized, other.Code, "addresses should have their own buckets")
+}
@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+// Purger periodically deletes full buckets from a shared store, which
+// unlike MemoryStore does not prune itself.
+type Purger struct {
+	Store interface {
+		DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	}
+	Interval time.Duration
+	Logger   *logging.Logger
+}
+
+// Run purges buckets until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := p.Store.DeleteExpired(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			p.Logger.Error(fmt.Errorf("could not purge rate limit buckets: %w", err))
+		}
+	}
+}
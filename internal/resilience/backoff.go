@@ -0,0 +1,39 @@
+package resilience
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff spaces out the attempts of background work failing over a long
+// time, such as publishing events or delivering webhooks.
+type Backoff struct {
+	Base time.Duration // Delay after the first failed attempt.
+	Max  time.Duration
+}
+
+// Delay returns the delay after the given number of failed attempts,
+// doubling from Base up to Max with up to 20% jitter so receivers
+// recovering from an outage aren't hit all at once.
+func (b Backoff) Delay(attempts int) time.Duration {
+	delay := b.Base
+	for i := 1; i < attempts && delay < b.Max; i++ {
+		delay *= 2
+	}
+
+	if delay > b.Max {
+		delay = b.Max
+	}
+
+	return delay - time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// OrDefault returns v, or def if v is not positive, for settings whose
+// zero value means the default.
+func OrDefault[T int | time.Duration](v, def T) T {
+	if v <= 0 {
+		return def
+	}
+
+	return v
+}
@@ -0,0 +1,38 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	tests := []struct {
+		attempts int
+		max      time.Duration
+	}{
+		{attempts: 1, max: time.Second},
+		{attempts: 2, max: 2 * time.Second},
+		{attempts: 3, max: 4 * time.Second},
+		{attempts: 10, max: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		// Act
+		delay := b.Delay(tt.attempts)
+
+		// Assert
+		assert.LessOrEqual(t, delay, tt.max, "attempts %d", tt.attempts)
+		assert.GreaterOrEqual(t, delay, tt.max*4/5, "attempts %d", tt.attempts)
+	}
+}
+
+func TestOrDefault(t *testing.T) {
+	assert.Equal(t, 5, OrDefault(0, 5))
+	assert.Equal(t, 5, OrDefault(-1, 5))
+	assert.Equal(t, 3, OrDefault(3, 5))
+	assert.Equal(t, time.Minute, OrDefault(0, time.Minute))
+}
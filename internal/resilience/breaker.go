@@ -0,0 +1,181 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+)
+
+// ErrOpen is returned by calls rejected by an open breaker.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a Breaker.
+type State int
+
+const (
+	// StateClosed lets calls through.
+	StateClosed State = iota
+	// StateHalfOpen lets a single trial call through.
+	StateHalfOpen
+	// StateOpen rejects calls.
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker opens after FailureThreshold consecutive failures, rejecting
+// calls with ErrOpen for OpenTimeout. It then lets a trial call through,
+// closing again if it succeeds and reopening if it fails.
+type Breaker struct {
+	Name string // Identifies the breaker in OnStateChange.
+
+	// Zero values are replaced by the defaults.
+	FailureThreshold int
+	OpenTimeout      time.Duration
+
+	// IsFailure reports whether err counts as a failure, every error does
+	// if nil. Errors of the caller, e.g. not found, should not.
+	IsFailure func(err error) bool
+	// OnStateChange is called on every state transition, if not nil. It is
+	// called with the breaker locked so it must not call the breaker.
+	OnStateChange func(name string, from, to State)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool // A trial call of the half-open breaker is in flight.
+
+	now func() time.Time // For tests, time.Now if nil.
+}
+
+// Do calls fn unless the breaker is open.
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := fn(ctx)
+	b.record(err)
+
+	return err
+}
+
+// State returns the current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+
+	return b.state
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+
+	switch b.state {
+	case StateOpen:
+		return ErrOpen
+	case StateHalfOpen:
+		if b.trial {
+			return ErrOpen
+		}
+		b.trial = true
+	}
+
+	return nil
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := err != nil && (b.IsFailure == nil || b.IsFailure(err))
+
+	if b.state == StateHalfOpen {
+		b.trial = false
+
+		if failed {
+			b.transition(StateOpen)
+		} else {
+			b.transition(StateClosed)
+		}
+
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+
+	threshold := b.FailureThreshold
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+
+	if b.state == StateClosed && b.failures >= threshold {
+		b.transition(StateOpen)
+	}
+}
+
+// expire half-opens the breaker once it has been open for OpenTimeout.
+func (b *Breaker) expire() {
+	timeout := b.OpenTimeout
+	if timeout <= 0 {
+		timeout = DefaultOpenTimeout
+	}
+
+	if b.state == StateOpen && b.clock().Sub(b.openedAt) >= timeout {
+		b.transition(StateHalfOpen)
+	}
+}
+
+func (b *Breaker) transition(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+
+	b.state = to
+	b.failures = 0
+
+	if to == StateOpen {
+		b.openedAt = b.clock()
+	}
+
+	if b.OnStateChange != nil {
+		b.OnStateChange(b.Name, from, to)
+	}
+}
+
+func (b *Breaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,81 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	// Arrange
+	var (
+		now         = time.Unix(1700000000, 0)
+		transitions []string
+		errCaller   = errors.New("not found")
+	)
+
+	b := &Breaker{
+		Name:             "db",
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		IsFailure:        func(err error) bool { return errors.Is(err, errTransient) },
+		OnStateChange: func(name string, from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+		now: func() time.Time { return now },
+	}
+
+	call := func(err error) error {
+		return b.Do(context.Background(), func(ctx context.Context) error { return err })
+	}
+
+	// Act & Assert
+	assert.Equal(t, errTransient, call(errTransient))
+	assert.Equal(t, errCaller, call(errCaller), "caller errors should reset failures")
+	assert.Equal(t, errTransient, call(errTransient))
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.Equal(t, errTransient, call(errTransient))
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, call(nil), ErrOpen, "open breaker should reject calls")
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.Equal(t, errTransient, call(errTransient), "failed trial should reopen")
+	assert.Equal(t, StateOpen, b.State())
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, call(nil), "successful trial should close")
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, transitions)
+}
+
+func TestBreakerSingleTrial(t *testing.T) {
+	// Arrange
+	now := time.Unix(1700000000, 0)
+	b := &Breaker{FailureThreshold: 1, OpenTimeout: time.Second, now: func() time.Time { return now }}
+
+	b.Do(context.Background(), func(ctx context.Context) error { return errTransient })
+	now = now.Add(time.Second)
+
+	// Act
+	var nested error
+	b.Do(context.Background(), func(ctx context.Context) error {
+		nested = b.Do(ctx, func(ctx context.Context) error { return nil })
+		return nil
+	})
+
+	// Assert
+	assert.ErrorIs(t, nested, ErrOpen, "half-open breaker should let a single trial through")
+	assert.Equal(t, StateClosed, b.State())
+}
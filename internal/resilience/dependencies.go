@@ -0,0 +1,18 @@
+package resilience
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+//go:generate moq -out mock_example_repository_test.go . exampleRepository
+type exampleRepository interface {
+	FindOneByID(ctx context.Context, id uuid.UUID) (example.Example, error)
+	Save(ctx context.Context, ex example.Example) error
+	List(ctx context.Context, q example.ListQuery) ([]example.Example, error)
+	Update(ctx context.Context, ex example.Example, expectedVersion *int) (example.Example, error)
+	Delete(ctx context.Context, id uuid.UUID, expectedVersion *int) error
+}
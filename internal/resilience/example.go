@@ -0,0 +1,100 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+// ExampleRepository decorates an example repository, retrying calls failing
+// with example.ErrTemporary and failing fast while Breaker is open.
+type ExampleRepository struct {
+	Repository exampleRepository
+	Retry      Retry
+	// Breaker is shared by all calls, nil disables it.
+	Breaker *Breaker
+	// InTx reports whether ctx carries a transaction, calls within one are
+	// not retried as a failed statement aborts the transaction. Every call
+	// is retried if nil.
+	InTx func(ctx context.Context) bool
+}
+
+func (r ExampleRepository) FindOneByID(ctx context.Context, id uuid.UUID) (ex example.Example, err error) {
+	err = r.do(ctx, func(ctx context.Context) error {
+		ex, err = r.Repository.FindOneByID(ctx, id)
+		return err
+	})
+
+	return ex, err
+}
+
+func (r ExampleRepository) Save(ctx context.Context, ex example.Example) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.Repository.Save(ctx, ex)
+	})
+}
+
+func (r ExampleRepository) List(ctx context.Context, q example.ListQuery) (examples []example.Example, err error) {
+	err = r.do(ctx, func(ctx context.Context) error {
+		examples, err = r.Repository.List(ctx, q)
+		return err
+	})
+
+	return examples, err
+}
+
+func (r ExampleRepository) Update(ctx context.Context, ex example.Example, expectedVersion *int) (updated example.Example, err error) {
+	err = r.do(ctx, func(ctx context.Context) error {
+		updated, err = r.Repository.Update(ctx, ex, expectedVersion)
+		return err
+	})
+
+	return updated, err
+}
+
+func (r ExampleRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion *int) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.Repository.Delete(ctx, id, expectedVersion)
+	})
+}
+
+func (r ExampleRepository) do(ctx context.Context, fn func(ctx context.Context) error) error {
+	call := func(ctx context.Context) error {
+		if r.Breaker == nil {
+			return fn(ctx)
+		}
+
+		err := r.Breaker.Do(ctx, fn)
+		if errors.Is(err, ErrOpen) {
+			return example.WrapError(fmt.Errorf("example repository: %w", err), example.ErrTemporary)
+		}
+
+		return err
+	}
+
+	if r.InTx != nil && r.InTx(ctx) {
+		return call(ctx)
+	}
+
+	retry := r.Retry
+	retry.IsRetryable = IsRetryable
+
+	return retry.Do(ctx, call)
+}
+
+// IsRetryable reports whether err is temporary and not caused by an open
+// breaker, which would reject a retry as well.
+func IsRetryable(err error) bool {
+	return errors.Is(err, example.ErrTemporary) && !errors.Is(err, ErrOpen)
+}
+
+// IsFailure reports whether err indicates a failing dependency, for
+// Breaker.IsFailure. Temporary errors of contention, such as serialization
+// failures, are retried but do not open the breaker.
+func IsFailure(err error) bool {
+	return example.IsUnavailable(err)
+}
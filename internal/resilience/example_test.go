@@ -0,0 +1,98 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+func TestExampleRepository(t *testing.T) {
+	temporary := example.Unavailable(example.WrapError(errors.New("connection refused"), example.ErrTemporary))
+	contention := example.WrapError(errors.New("deadlock detected"), example.ErrTemporary)
+
+	tests := []struct {
+		name          string
+		errs          []error
+		inTx          bool
+		expectedCalls int
+		expectedErr   error
+	}{
+		{
+			name:          "should retry temporary errors",
+			errs:          []error{temporary, nil},
+			expectedCalls: 2,
+		},
+		{
+			name:          "should not retry other errors",
+			errs:          []error{example.WrapError(errors.New("no rows"), example.ErrNotFound)},
+			expectedCalls: 1,
+			expectedErr:   example.ErrNotFound,
+		},
+		{
+			name:          "should not retry within transaction",
+			errs:          []error{temporary, nil},
+			inTx:          true,
+			expectedCalls: 1,
+			expectedErr:   example.ErrTemporary,
+		},
+		{
+			name:          "should fail fast once breaker opens",
+			errs:          []error{temporary, temporary, temporary},
+			expectedCalls: 2,
+			expectedErr:   ErrOpen,
+		},
+		{
+			name:          "should retry contention without opening breaker",
+			errs:          []error{contention, contention, contention},
+			expectedCalls: 3,
+			expectedErr:   example.ErrTemporary,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			expected := example.Example{ID: uuid.New(), Name: "example"}
+
+			var calls int
+			repo := &exampleRepositoryMock{
+				FindOneByIDFunc: func(ctx context.Context, id uuid.UUID) (example.Example, error) {
+					err := tt.errs[calls]
+					calls++
+					if err != nil {
+						return example.Example{}, err
+					}
+					return expected, nil
+				},
+			}
+
+			r := ExampleRepository{
+				Repository: repo,
+				Retry:      Retry{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+				Breaker:    &Breaker{FailureThreshold: 2, IsFailure: IsFailure},
+				InTx:       func(ctx context.Context) bool { return tt.inTx },
+			}
+
+			// Act
+			ex, err := r.FindOneByID(context.Background(), expected.ID)
+
+			// Assert
+			assert.Equal(t, tt.expectedCalls, calls)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, ex)
+		})
+	}
+}
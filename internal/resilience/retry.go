@@ -0,0 +1,93 @@
+// Package resilience keeps transient failures of dependencies from failing
+// requests, by retrying with backoff, and keeps a failing dependency from
+// being hammered, by failing fast through a circuit breaker.
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 50 * time.Millisecond
+	DefaultMaxDelay    = time.Second
+)
+
+// Retry retries failed calls with exponential backoff and full jitter.
+type Retry struct {
+	// Zero values are replaced by the defaults.
+	MaxAttempts int // Including the first call.
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	// IsRetryable reports whether a call failing with err may be retried,
+	// every error is if nil.
+	IsRetryable func(err error) bool
+	// OnRetry is called before sleeping delay to make another attempt, if
+	// not nil.
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable
+// or MaxAttempts is reached, returning the last error. Retries are given up
+// when ctx is done or its deadline would pass while sleeping.
+func (r Retry) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	maxAttempts := r.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= maxAttempts || (r.IsRetryable != nil && !r.IsRetryable(err)) {
+			return err
+		}
+
+		delay := r.delay(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+
+		if r.OnRetry != nil {
+			r.OnRetry(attempt, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns a random delay up to BaseDelay doubled per failed attempt,
+// capped at MaxDelay. Full jitter spreads the retries of concurrent callers
+// failing at once.
+func (r Retry) delay(attempt int) time.Duration {
+	var (
+		base     = r.BaseDelay
+		maxDelay = r.MaxDelay
+	)
+
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxDelay
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
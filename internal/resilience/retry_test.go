@@ -0,0 +1,104 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("transient")
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		retryable     bool
+		timeout       time.Duration
+		expectedCalls int
+		expectedErr   error
+	}{
+		{
+			name:          "should succeed after transient failures",
+			failures:      2,
+			retryable:     true,
+			expectedCalls: 3,
+		},
+		{
+			name:          "should give up after max attempts",
+			failures:      5,
+			retryable:     true,
+			expectedCalls: 3,
+			expectedErr:   errTransient,
+		},
+		{
+			name:          "should not retry errors that are not retryable",
+			failures:      5,
+			expectedCalls: 1,
+			expectedErr:   errTransient,
+		},
+		{
+			name:          "should not sleep past deadline",
+			failures:      5,
+			retryable:     true,
+			timeout:       time.Microsecond,
+			expectedCalls: 1,
+			expectedErr:   errTransient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			var retries int
+			r := Retry{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    time.Millisecond,
+				IsRetryable: func(err error) bool { return tt.retryable },
+				OnRetry:     func(attempt int, delay time.Duration, err error) { retries++ },
+			}
+
+			var calls int
+			fn := func(ctx context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return errTransient
+				}
+				return nil
+			}
+
+			// Act
+			err := r.Do(ctx, fn)
+
+			// Assert
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedCalls, calls)
+			assert.Equal(t, tt.expectedCalls-1, retries)
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	r := Retry{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	for attempt, max := range map[int]time.Duration{
+		1: 10 * time.Millisecond,
+		2: 20 * time.Millisecond,
+		3: 40 * time.Millisecond,
+		8: 50 * time.Millisecond,
+	} {
+		for i := 0; i < 100; i++ {
+			assert.LessOrEqual(t, r.delay(attempt), max, "attempt %d", attempt)
+		}
+	}
+}
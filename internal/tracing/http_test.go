@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportedSpan is the part of a span written by the file exporter that is
+// asserted on.
+type exportedSpan struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+	}
+	Parent struct {
+		SpanID string
+	}
+	Status struct {
+		Code string
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	// Arrange
+	file := filepath.Join(t.TempDir(), "spans.json")
+
+	shutdown, err := Setup(Config{
+		Exporter:    ExporterJSONFile,
+		File:        file,
+		ServiceName: "test",
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	require.NoError(t, shutdown(context.Background()))
+
+	// Assert
+	spans := readSpans(t, file)
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "GET /items/{id}", spans[0].Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID, "should continue trace of traceparent")
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID)
+	assert.Equal(t, "Unset", spans[0].Status.Code)
+
+	assert.Equal(t, "GET /fail", spans[1].Name)
+	assert.Equal(t, "Error", spans[1].Status.Code)
+}
+
+func readSpans(t *testing.T, file string) []exportedSpan {
+	t.Helper()
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var spans []exportedSpan
+	for dec := json.NewDecoder(f); ; {
+		var span exportedSpan
+		err := dec.Decode(&span)
+		if errors.Is(err, io.EOF) {
+			return spans
+		}
+		require.NoError(t, err)
+
+		spans = append(spans, span)
+	}
+}
@@ -0,0 +1,94 @@
+// Package tracing sets up OpenTelemetry tracing and instruments http
+// requests with spans continuing W3C traceparent headers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// Exporter is where finished spans are exported to.
+type Exporter string
+
+const (
+	ExporterNone     Exporter = "none"      // Spans are not recorded, trace context is still propagated.
+	ExporterStdout   Exporter = "stdout"    // Spans are written as JSON to stdout.
+	ExporterJSONFile Exporter = "json-file" // Spans are written as JSON to a file.
+)
+
+// The JSON written by the stdout and json-file exporters is the encoding of
+// the spans of the SDK, one object per line, not OTLP.
+
+// Config configures tracing.
+type Config struct {
+	Exporter    Exporter
+	File        string  // Path of the file spans are appended to, for ExporterJSONFile.
+	ServiceName string  // Reported as service.name resource attribute.
+	SampleRatio float64 // Ratio of new traces to sample, traces of sampled parents are always sampled.
+}
+
+// Setup installs the global tracer provider and W3C trace context
+// propagator. The returned shutdown func flushes pending spans and must be
+// called before exiting.
+func Setup(c Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var w io.Writer
+	closer := func() error { return nil }
+
+	switch c.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		w = os.Stdout
+	case ExporterJSONFile:
+		f, err := os.OpenFile(c.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("could not open trace file: %w", err)
+		}
+
+		w, closer = f, f.Close
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", c.Exporter)
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		closer()
+		return nil, fmt.Errorf("could not create trace exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(c.ServiceName),
+		)),
+	)
+
+	otel.SetTracerProvider(tp)
+
+	shutdown = func(ctx context.Context) error {
+		if err := tp.Shutdown(ctx); err != nil {
+			closer()
+			return fmt.Errorf("could not flush spans: %w", err)
+		}
+
+		return closer()
+	}
+
+	return shutdown, nil
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when dialing an address deliveries must
+// not reach.
+var ErrForbiddenAddress = errors.New("address is loopback, private or link-local")
+
+// metadataIP is the instance metadata service of cloud providers.
+var metadataIP = net.IPv4(169, 254, 169, 254)
+
+// forbiddenIP reports whether ip is internal to the network of the service,
+// so that subscriptions cannot make it send requests to itself or its
+// neighbours.
+func forbiddenIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() ||
+		ip.Equal(metadataIP)
+}
+
+// forbiddenHost reports whether host is a forbidden IP or a name of the
+// loopback. Other names are only checked once resolved, see DialControl.
+func forbiddenHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && forbiddenIP(ip)
+}
+
+// DialControl refuses connections to forbidden addresses. It checks the
+// resolved address being dialed, so a name resolving to an internal
+// address, even after the subscription was validated, is refused too.
+func DialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("could not parse dialed address %q: %w", address, err)
+	}
+
+	if ip := net.ParseIP(host); ip == nil || forbiddenIP(ip) {
+		return fmt.Errorf("could not dial %s: %w", address, ErrForbiddenAddress)
+	}
+
+	return nil
+}
+
+// NewClient returns a client for deliveries with timeout, refusing to
+// connect to forbidden addresses. Proxies of the environment are not used
+// as they would resolve names themselves.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   DialControl,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
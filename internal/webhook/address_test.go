@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionDTOValidateURL(t *testing.T) {
+	tests := []struct {
+		url           string
+		expectedValid bool
+	}{
+		{url: "https://example.com/hook", expectedValid: true},
+		{url: "http://93.184.216.34/hook", expectedValid: true},
+		{url: "ftp://example.com/hook"},
+		{url: "http://localhost:8080/hook"},
+		{url: "http://api.localhost/hook"},
+		{url: "http://127.0.0.1/hook"},
+		{url: "http://[::1]/hook"},
+		{url: "http://10.0.0.1/hook"},
+		{url: "http://172.16.3.4/hook"},
+		{url: "http://192.168.1.1/hook"},
+		{url: "http://169.254.169.254/latest/meta-data"},
+		{url: "http://[fe80::1]/hook"},
+		{url: "http://0.0.0.0/hook"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			// Act
+			err := SubscriptionDTO{URL: tt.url}.Validate()
+
+			// Assert
+			if tt.expectedValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	tests := []struct {
+		address       string
+		expectedAllow bool
+	}{
+		{address: "93.184.216.34:443", expectedAllow: true},
+		{address: "[2606:2800:220:1::1]:443", expectedAllow: true},
+		{address: "127.0.0.1:80"},
+		{address: "10.1.2.3:80"},
+		{address: "169.254.169.254:80"},
+		{address: "[fd00::1]:80"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			// Act
+			err := DialControl("tcp", tt.address, nil)
+
+			// Assert
+			if tt.expectedAllow {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrForbiddenAddress)
+			}
+		})
+	}
+}
+
+func TestNewClientRefusesLoopback(t *testing.T) {
+	// Arrange
+	var called bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	client := NewClient(time.Second)
+
+	// Act
+	_, err := client.Post(receiver.URL, "application/json", nil)
+
+	// Assert
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+	assert.False(t, called)
+}
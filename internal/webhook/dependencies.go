@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+//go:generate moq -out mock_repository_test.go . repository
+type repository interface {
+	SaveSubscription(ctx context.Context, s Subscription) error
+	FindSubscription(ctx context.Context, id uuid.UUID) (Subscription, error)
+	// ListSubscriptions lists the subscriptions of owner, all if empty.
+	ListSubscriptions(ctx context.Context, owner string) ([]Subscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	// ListDeliveries lists the latest deliveries of a subscription, newest
+	// first.
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]Delivery, error)
+	// ListAttempts lists the attempts of a delivery of a subscription,
+	// oldest first.
+	ListAttempts(ctx context.Context, subscriptionID, deliveryID uuid.UUID) ([]Attempt, error)
+}
+
+//go:generate moq -out mock_store_test.go . store
+type store interface {
+	// EnqueueDeliveries queues e for delivery to every subscription of its
+	// type, once per subscription however often it is called.
+	EnqueueDeliveries(ctx context.Context, e example.Event) error
+	// ClaimDueDeliveries returns up to limit pending deliveries due at now,
+	// leased to the caller by postponing their next attempt to leaseUntil,
+	// so other dispatchers skip them while they are sent.
+	ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]PendingDelivery, error)
+	// UpdateDelivery stores the status, attempts and next attempt of d.
+	UpdateDelivery(ctx context.Context, d Delivery) error
+	SaveAttempt(ctx context.Context, a Attempt) error
+}
+
+//go:generate moq -out mock_tx_manager_test.go . txManager
+type txManager interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
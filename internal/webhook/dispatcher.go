@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bratteby/go-service-template/internal/example"
+	"github.com/bratteby/go-service-template/internal/logging"
+	"github.com/bratteby/go-service-template/internal/resilience"
+)
+
+const (
+	DefaultMaxAttempts  = 10
+	DefaultBaseBackoff  = 30 * time.Second
+	DefaultMaxBackoff   = 6 * time.Hour
+	DefaultPollInterval = 5 * time.Second
+	DefaultBatchSize    = 20
+	DefaultLease        = 5 * time.Minute
+)
+
+// Publisher queues events for delivery to their subscriptions, it is the
+// outbox publisher of webhooks.
+type Publisher struct {
+	Store store
+}
+
+func (p Publisher) Publish(ctx context.Context, e example.Event) error {
+	if err := p.Store.EnqueueDeliveries(ctx, e); err != nil {
+		return fmt.Errorf("could not enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// Dispatcher sends due deliveries. Failed deliveries are retried with
+// exponential backoff until MaxAttempts, after which they are dead.
+//
+// Deliveries are leased rather than locked while sent, so no transaction is
+// held open during requests. A delivery whose result could not be recorded,
+// e.g. as the dispatcher stopped, is sent again once its lease expires.
+type Dispatcher struct {
+	Store     store
+	TxManager txManager
+	Client    *http.Client // http.DefaultClient if nil, it should have a timeout.
+	Logger    *logging.Logger
+
+	// Zero values are replaced by the defaults.
+	MaxAttempts  int
+	BaseBackoff  time.Duration // Delay after the first failed attempt, doubled on every attempt.
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	BatchSize    int
+	// Lease hides claimed deliveries from other dispatchers while sent, it
+	// must exceed the timeout of Client.
+	Lease time.Duration
+
+	now func() time.Time // For tests, time.Now if nil.
+}
+
+// Run dispatches deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(resilience.OrDefault(d.PollInterval, DefaultPollInterval))
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := d.DispatchBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				d.Logger.Error(fmt.Errorf("could not dispatch webhooks: %w", err))
+			}
+
+			if err != nil || n < resilience.OrDefault(d.BatchSize, DefaultBatchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchBatch sends a batch of due deliveries concurrently and returns
+// the number claimed. The result of every delivery is recorded in its own
+// transaction, the first error recording one is returned.
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	now := d.clock()
+
+	deliveries, err := d.Store.ClaimDueDeliveries(ctx, now, now.Add(resilience.OrDefault(d.Lease, DefaultLease)), resilience.OrDefault(d.BatchSize, DefaultBatchSize))
+	if err != nil {
+		return 0, fmt.Errorf("could not claim deliveries: %w", err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for _, pd := range deliveries {
+		wg.Add(1)
+		go func(pd PendingDelivery) {
+			defer wg.Done()
+
+			if err := d.record(ctx, pd.Delivery, d.send(ctx, pd)); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}(pd)
+	}
+
+	wg.Wait()
+
+	return len(deliveries), firstErr
+}
+
+// record stores attempt and the delivery after it.
+func (d *Dispatcher) record(ctx context.Context, delivery Delivery, attempt Attempt) error {
+	return d.TxManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := d.Store.SaveAttempt(ctx, attempt); err != nil {
+			return fmt.Errorf("could not log attempt of delivery %s: %w", delivery.ID, err)
+		}
+
+		if err := d.Store.UpdateDelivery(ctx, d.next(delivery, attempt)); err != nil {
+			return fmt.Errorf("could not update delivery %s: %w", delivery.ID, err)
+		}
+
+		return nil
+	})
+}
+
+// send posts the signed payload of a delivery.
+func (d *Dispatcher) send(ctx context.Context, pd PendingDelivery) Attempt {
+	attempt := Attempt{
+		ID:          uuid.New(),
+		DeliveryID:  pd.ID,
+		AttemptedAt: d.clock(),
+	}
+
+	t1 := time.Now()
+	attempt.StatusCode, attempt.Error = d.post(ctx, pd, attempt.AttemptedAt)
+	attempt.DurationMS = time.Since(t1).Milliseconds()
+
+	return attempt
+}
+
+// post posts the payload of pd signed at, and returns the status code of
+// the response, zero if none, and the error of the attempt if it failed.
+func (d *Dispatcher) post(ctx context.Context, pd PendingDelivery, at time.Time) (int, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pd.URL, bytes.NewReader(pd.Payload))
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, pd.EventID.String())
+	req.Header.Set(TimestampHeader, fmt.Sprint(at.Unix()))
+	req.Header.Set(SignatureHeader, Sign(pd.Secret, at, pd.Payload))
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Sprintf("receiver responded %s", resp.Status)
+	}
+
+	return resp.StatusCode, ""
+}
+
+// next returns the delivery after attempt.
+func (d *Dispatcher) next(delivery Delivery, attempt Attempt) Delivery {
+	delivery.Attempts++
+
+	switch {
+	case attempt.Error == "":
+		delivery.Status = DeliverySucceeded
+	case delivery.Attempts >= resilience.OrDefault(d.MaxAttempts, DefaultMaxAttempts):
+		delivery.Status = DeliveryDead
+		d.Logger.ErrorWith("webhook delivery is dead", "deliveryID", delivery.ID.String(), "attempts", delivery.Attempts, "error", attempt.Error)
+	default:
+		delivery.NextAttemptAt = attempt.AttemptedAt.Add(d.backoff(delivery.Attempts))
+	}
+
+	return delivery
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	return resilience.Backoff{
+		Base: resilience.OrDefault(d.BaseBackoff, DefaultBaseBackoff),
+		Max:  resilience.OrDefault(d.MaxBackoff, DefaultMaxBackoff),
+	}.Delay(attempts)
+}
+
+func (d *Dispatcher) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+
+	return time.Now().UTC()
+}
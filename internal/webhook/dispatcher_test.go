@@ -0,0 +1,246 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/logging"
+)
+
+func TestDispatchBatch(t *testing.T) {
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		status         int
+		attempts       int
+		expectedStatus DeliveryStatus
+		expectedNext   bool
+	}{
+		{
+			name:           "delivered",
+			status:         http.StatusNoContent,
+			expectedStatus: DeliverySucceeded,
+		},
+		{
+			name:           "retried after failure",
+			status:         http.StatusServiceUnavailable,
+			attempts:       1,
+			expectedStatus: DeliveryPending,
+			expectedNext:   true,
+		},
+		{
+			name:           "dead after max attempts",
+			status:         http.StatusInternalServerError,
+			attempts:       2,
+			expectedStatus: DeliveryDead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			payload := []byte(`{"type":"example.created"}`)
+
+			var inTx atomic.Bool
+
+			var received *http.Request
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, payload, body)
+				assert.NoError(t, Verify("whsec_test", r.Header, body, now, time.Minute))
+				assert.False(t, inTx.Load(), "delivery should be sent outside of a transaction")
+
+				received = r
+				w.WriteHeader(tt.status)
+			}))
+			defer receiver.Close()
+
+			pending := PendingDelivery{
+				Delivery: Delivery{
+					ID:       uuid.New(),
+					EventID:  uuid.New(),
+					Payload:  payload,
+					Status:   DeliveryPending,
+					Attempts: tt.attempts,
+				},
+				URL:    receiver.URL,
+				Secret: "whsec_test",
+			}
+
+			store := &storeMock{
+				ClaimDueDeliveriesFunc: func(ctx context.Context, at, leaseUntil time.Time, limit int) ([]PendingDelivery, error) {
+					return []PendingDelivery{pending}, nil
+				},
+				SaveAttemptFunc: func(ctx context.Context, a Attempt) error {
+					assert.True(t, inTx.Load(), "attempt should be saved in a transaction")
+					return nil
+				},
+				UpdateDeliveryFunc: func(ctx context.Context, d Delivery) error {
+					return nil
+				},
+			}
+
+			d := &Dispatcher{
+				Store: store,
+				TxManager: &txManagerMock{
+					WithTxFunc: func(ctx context.Context, fn func(ctx context.Context) error) error {
+						inTx.Store(true)
+						defer inTx.Store(false)
+
+						return fn(ctx)
+					},
+				},
+				Client:      receiver.Client(),
+				Logger:      logging.New(io.Discard, logging.Config{}),
+				MaxAttempts: 3,
+				BaseBackoff: time.Minute,
+				MaxBackoff:  time.Hour,
+				Lease:       time.Minute,
+				now:         func() time.Time { return now },
+			}
+
+			// Act
+			n, err := d.DispatchBatch(context.Background())
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+
+			require.Len(t, store.ClaimDueDeliveriesCalls(), 1)
+			assert.Equal(t, now.Add(time.Minute), store.ClaimDueDeliveriesCalls()[0].LeaseUntil)
+
+			require.NotNil(t, received)
+			assert.Equal(t, pending.EventID.String(), received.Header.Get(IDHeader))
+
+			require.Len(t, store.SaveAttemptCalls(), 1)
+			attempt := store.SaveAttemptCalls()[0].A
+			assert.Equal(t, pending.ID, attempt.DeliveryID)
+			assert.Equal(t, tt.status, attempt.StatusCode)
+			assert.Equal(t, now, attempt.AttemptedAt)
+
+			require.Len(t, store.UpdateDeliveryCalls(), 1)
+			updated := store.UpdateDeliveryCalls()[0].D
+			assert.Equal(t, tt.expectedStatus, updated.Status)
+			assert.Equal(t, tt.attempts+1, updated.Attempts)
+			if tt.expectedNext {
+				assert.True(t, updated.NextAttemptAt.After(now), "next attempt should be after now")
+			}
+		})
+	}
+}
+
+func TestDispatchBatchRecordsResultsSeparately(t *testing.T) {
+	// Arrange
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	failing, succeeding := uuid.New(), uuid.New()
+
+	var mu sync.Mutex
+	var updated []uuid.UUID
+
+	store := &storeMock{
+		ClaimDueDeliveriesFunc: func(ctx context.Context, at, leaseUntil time.Time, limit int) ([]PendingDelivery, error) {
+			return []PendingDelivery{
+				{Delivery: Delivery{ID: failing, Status: DeliveryPending}, URL: receiver.URL},
+				{Delivery: Delivery{ID: succeeding, Status: DeliveryPending}, URL: receiver.URL},
+			}, nil
+		},
+		SaveAttemptFunc: func(ctx context.Context, a Attempt) error {
+			if a.DeliveryID == failing {
+				return errors.New("connection lost")
+			}
+			return nil
+		},
+		UpdateDeliveryFunc: func(ctx context.Context, d Delivery) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			updated = append(updated, d.ID)
+			return nil
+		},
+	}
+
+	txManager := &txManagerMock{
+		WithTxFunc: func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		},
+	}
+
+	d := &Dispatcher{
+		Store:     store,
+		TxManager: txManager,
+		Client:    receiver.Client(),
+		Logger:    logging.New(io.Discard, logging.Config{}),
+	}
+
+	// Act
+	n, err := d.DispatchBatch(context.Background())
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, txManager.WithTxCalls(), 2)
+	assert.Equal(t, []uuid.UUID{succeeding}, updated)
+}
+
+func TestSendDuration(t *testing.T) {
+	// Arrange
+	const delay = 50 * time.Millisecond
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	d := &Dispatcher{Client: receiver.Client()}
+
+	// Act
+	attempt := d.send(context.Background(), PendingDelivery{
+		Delivery: Delivery{ID: uuid.New()},
+		URL:      receiver.URL,
+	})
+
+	// Assert
+	assert.Empty(t, attempt.Error)
+	assert.Equal(t, http.StatusNoContent, attempt.StatusCode)
+	assert.GreaterOrEqual(t, attempt.DurationMS, delay.Milliseconds())
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{BaseBackoff: time.Minute, MaxBackoff: 10 * time.Minute}
+
+	tests := []struct {
+		attempts int
+		max      time.Duration
+	}{
+		{attempts: 1, max: time.Minute},
+		{attempts: 2, max: 2 * time.Minute},
+		{attempts: 3, max: 4 * time.Minute},
+		{attempts: 10, max: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		// Act
+		backoff := d.backoff(tt.attempts)
+
+		// Assert
+		assert.LessOrEqual(t, backoff, tt.max, "attempts %d", tt.attempts)
+		assert.GreaterOrEqual(t, backoff, tt.max*4/5, "attempts %d", tt.attempts)
+	}
+}
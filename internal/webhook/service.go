@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+const (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 100
+)
+
+// Service manages the webhook subscriptions of principals. Principals only
+// see their own subscriptions unless granted example.ScopeAdmin.
+type Service struct {
+	Repository repository
+}
+
+// CreateSubscription subscribes the principal of ctx to the events of its
+// examples, or of all examples if granted example.ScopeAdmin. The returned
+// subscription holds the signing secret.
+func (s Service) CreateSubscription(ctx context.Context, dto SubscriptionDTO) (Subscription, error) {
+	if err := dto.Validate(); err != nil {
+		return Subscription{}, err
+	}
+
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return Subscription{}, example.WrapError(fmt.Errorf("no principal to own subscription"), example.ErrForbidden)
+	}
+
+	sub, err := newSubscription(dto, p.ID(), p.HasScope(example.ScopeAdmin))
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	if err := s.Repository.SaveSubscription(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("could not store subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// ListSubscriptions lists the subscriptions visible to the principal of ctx.
+func (s Service) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, example.WrapError(fmt.Errorf("no principal to list subscriptions of"), example.ErrForbidden)
+	}
+
+	owner := p.ID()
+	if p.HasScope(example.ScopeAdmin) {
+		owner = ""
+	}
+
+	subs, err := s.Repository.ListSubscriptions(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("could not list subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+func (s Service) GetSubscription(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	return s.ownedSubscription(ctx, id)
+}
+
+func (s Service) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.ownedSubscription(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.Repository.DeleteSubscription(ctx, id); err != nil {
+		return fmt.Errorf("could not delete subscription %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// ListDeliveries lists the latest deliveries of a subscription, a zero
+// limit means DefaultDeliveryLimit.
+func (s Service) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]Delivery, error) {
+	if limit == 0 {
+		limit = DefaultDeliveryLimit
+	}
+
+	if limit < 1 || limit > MaxDeliveryLimit {
+		return nil, example.InvalidField("limit", "must be between 1 and %d", MaxDeliveryLimit)
+	}
+
+	if _, err := s.ownedSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.Repository.ListDeliveries(ctx, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not list deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ListAttempts lists the attempts of a delivery of a subscription.
+func (s Service) ListAttempts(ctx context.Context, subscriptionID, deliveryID uuid.UUID) ([]Attempt, error) {
+	if _, err := s.ownedSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.Repository.ListAttempts(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("could not list attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// ownedSubscription returns the subscription if visible to the principal of
+// ctx. Subscriptions of others are reported as not found rather than
+// forbidden, so their existence isn't disclosed.
+func (s Service) ownedSubscription(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return Subscription{}, example.WrapError(fmt.Errorf("no principal to authorize"), example.ErrForbidden)
+	}
+
+	sub, err := s.Repository.FindSubscription(ctx, id)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("could not get subscription %s: %w", id, err)
+	}
+
+	if sub.Owner != p.ID() && !p.HasScope(example.ScopeAdmin) {
+		return Subscription{}, example.WrapError(fmt.Errorf("%s does not own subscription %s", p.ID(), id), example.ErrNotFound)
+	}
+
+	return sub, nil
+}
@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bratteby/go-service-template/internal/auth"
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+func TestCreateSubscriptionOwners(t *testing.T) {
+	tests := []struct {
+		name              string
+		principal         auth.Principal
+		expectedAllOwners bool
+	}{
+		{
+			name:      "writer subscribes to its examples",
+			principal: auth.Principal{Subject: "alice", Method: auth.MethodBasic, Scopes: []string{example.ScopeWrite}},
+		},
+		{
+			name:              "admin subscribes to all examples",
+			principal:         auth.Principal{Subject: "admin", Method: auth.MethodBasic, Scopes: []string{example.ScopeWrite, example.ScopeAdmin}},
+			expectedAllOwners: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := &repositoryMock{
+				SaveSubscriptionFunc: func(ctx context.Context, s Subscription) error { return nil },
+			}
+			s := Service{Repository: repo}
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+
+			// Act
+			sub, err := s.CreateSubscription(ctx, SubscriptionDTO{URL: "https://example.com/hook"})
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.principal.ID(), sub.Owner)
+			assert.Equal(t, tt.expectedAllOwners, sub.AllOwners)
+			require.Len(t, repo.SaveSubscriptionCalls(), 1)
+			assert.Equal(t, sub, repo.SaveSubscriptionCalls()[0].S)
+		})
+	}
+}
+
+func TestSubscriptionsAreOwnedPerAuthMethod(t *testing.T) {
+	var (
+		basic = auth.Principal{Subject: "alice", Method: auth.MethodBasic, Scopes: []string{example.ScopeWrite}}
+		jwt   = auth.Principal{Subject: "alice", Method: auth.MethodJWT, Scopes: []string{example.ScopeWrite}}
+	)
+
+	tests := []struct {
+		name          string
+		owner         auth.Principal
+		principal     auth.Principal
+		expectedError error
+	}{
+		{name: "owner gets subscription", owner: basic, principal: basic},
+		{name: "jwt principal is denied subscription of basic principal", owner: basic, principal: jwt, expectedError: example.ErrNotFound},
+		{name: "basic principal is denied subscription of jwt principal", owner: jwt, principal: basic, expectedError: example.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			sub := Subscription{ID: uuid.New(), Owner: tt.owner.ID()}
+			repo := &repositoryMock{
+				FindSubscriptionFunc: func(ctx context.Context, id uuid.UUID) (Subscription, error) { return sub, nil },
+				ListSubscriptionsFunc: func(ctx context.Context, owner string) ([]Subscription, error) {
+					if owner != sub.Owner {
+						return nil, nil
+					}
+					return []Subscription{sub}, nil
+				},
+			}
+			s := Service{Repository: repo}
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+
+			// Act
+			got, err := s.GetSubscription(ctx, sub.ID)
+			listed, listErr := s.ListSubscriptions(ctx)
+
+			// Assert
+			require.NoError(t, listErr)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Empty(t, listed)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, sub, got)
+			assert.Equal(t, []Subscription{sub}, listed)
+		})
+	}
+}
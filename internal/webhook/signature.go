@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a delivery request.
+const (
+	// IDHeader is the event ID, for receivers to dedupe redeliveries.
+	IDHeader = "Webhook-Id"
+	// TimestampHeader is the unix time the request was signed at.
+	TimestampHeader = "Webhook-Timestamp"
+	// SignatureHeader is v1=<hex HMAC-SHA256 of "<timestamp>.<body>">.
+	SignatureHeader = "Webhook-Signature"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleTimestamp   = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign returns the signature header value of body sent at timestamp.
+// Signing the timestamp with the body keeps captured requests from being
+// replayed later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(body)
+
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify verifies the signature of a delivery request received at now, as
+// receivers should do. Requests signed more than tolerance apart from now
+// are rejected.
+func Verify(secret string, header http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s header: %w", TimestampHeader, ErrInvalidSignature)
+	}
+
+	timestamp := time.Unix(unix, 0)
+	if d := now.Sub(timestamp); d > tolerance || d < -tolerance {
+		return ErrStaleTimestamp
+	}
+
+	expected := Sign(secret, timestamp, body)
+	for _, signature := range strings.Split(header.Get(SignatureHeader), " ") {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	var (
+		secret = "whsec_test"
+		body   = []byte(`{"id":"1"}`)
+		signed = time.Unix(1700000000, 0)
+	)
+
+	header := func(signature string) http.Header {
+		h := http.Header{}
+		h.Set(TimestampHeader, "1700000000")
+		h.Set(SignatureHeader, signature)
+		return h
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		now    time.Time
+		err    error
+	}{
+		{
+			name:   "valid",
+			header: header(Sign(secret, signed, body)),
+			body:   body,
+			now:    signed.Add(time.Minute),
+		},
+		{
+			name:   "one of several signatures valid",
+			header: header("v1=00 " + Sign(secret, signed, body)),
+			body:   body,
+			now:    signed,
+		},
+		{
+			name:   "tampered body",
+			header: header(Sign(secret, signed, body)),
+			body:   []byte(`{"id":"2"}`),
+			now:    signed,
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "other secret",
+			header: header(Sign("whsec_other", signed, body)),
+			body:   body,
+			now:    signed,
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "stale timestamp",
+			header: header(Sign(secret, signed, body)),
+			body:   body,
+			now:    signed.Add(10 * time.Minute),
+			err:    ErrStaleTimestamp,
+		},
+		{
+			name:   "missing timestamp",
+			header: http.Header{SignatureHeader: []string{Sign(secret, signed, body)}},
+			body:   body,
+			now:    signed,
+			err:    ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := Verify(secret, tt.header, tt.body, tt.now, 5*time.Minute)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package webhook manages webhook subscriptions and delivers the domain
+// events of examples to them as signed HTTP callbacks.
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bratteby/go-service-template/internal/example"
+)
+
+// Subscription subscribes a URL to events.
+type Subscription struct {
+	ID         uuid.UUID           `json:"id"`
+	URL        string              `json:"url"`
+	EventTypes []example.EventType `json:"event_types"` // Empty subscribes to all events.
+	// Secret signs the deliveries, only returned when the subscription is
+	// created.
+	Secret string `json:"secret,omitempty"`
+	Owner  string `json:"owner"` // ID of the principal that created the subscription.
+	// AllOwners subscribes to the events of the examples of every owner,
+	// set if the owner is an admin. Otherwise only the events of the
+	// examples of Owner are delivered.
+	AllOwners bool      `json:"all_owners"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SubscriptionDTO is the request to create a subscription.
+type SubscriptionDTO struct {
+	URL        string              `json:"url"`
+	EventTypes []example.EventType `json:"event_types"`
+}
+
+var eventTypes = []example.EventType{
+	example.EventExampleCreated,
+	example.EventExampleUpdated,
+	example.EventExampleDeleted,
+}
+
+// Validate returns a *example.ValidationError with every invalid field.
+func (dto SubscriptionDTO) Validate() error {
+	var v example.ValidationError
+
+	u, err := url.Parse(dto.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.Add("url", "must be an absolute http or https URL")
+	} else if forbiddenHost(u.Hostname()) {
+		v.Add("url", "must not address a loopback, private or link-local host")
+	}
+
+	for i, t := range dto.EventTypes {
+		if !containsEventType(eventTypes, t) {
+			v.Add(fmt.Sprintf("event_types[%d]", i), "must be one of %v", eventTypes)
+		}
+	}
+
+	return v.Err()
+}
+
+func newSubscription(dto SubscriptionDTO, owner string, allOwners bool) (Subscription, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	types := dto.EventTypes
+	if types == nil {
+		types = []example.EventType{}
+	}
+
+	return Subscription{
+		ID:         uuid.New(),
+		URL:        dto.URL,
+		EventTypes: types,
+		Secret:     secret,
+		Owner:      owner,
+		AllOwners:  allOwners,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// generateSecret returns a random signing secret.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate webhook secret: %w", err)
+	}
+
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func containsEventType(types []example.EventType, t example.EventType) bool {
+	for _, s := range types {
+		if s == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeliveryStatus is the state of a delivery.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // Not yet delivered, retried at NextAttemptAt.
+	DeliverySucceeded DeliveryStatus = "succeeded" // Acknowledged with a 2xx response.
+	DeliveryDead      DeliveryStatus = "dead"      // Given up after the maximum attempts.
+)
+
+// Delivery is an event queued for delivery to a subscription.
+type Delivery struct {
+	ID             uuid.UUID         `json:"id"`
+	SubscriptionID uuid.UUID         `json:"subscription_id"`
+	EventID        uuid.UUID         `json:"event_id"`
+	EventType      example.EventType `json:"event_type"`
+	Payload        json.RawMessage   `json:"payload"` // The event as posted.
+	Status         DeliveryStatus    `json:"status"`
+	Attempts       int               `json:"attempts"`
+	NextAttemptAt  time.Time         `json:"next_attempt_at"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// PendingDelivery is a due delivery with what is needed to send it.
+type PendingDelivery struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+// Attempt is a logged delivery attempt.
+type Attempt struct {
+	ID          uuid.UUID `json:"id"`
+	DeliveryID  uuid.UUID `json:"delivery_id"`
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty"` // Zero if no response was received.
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+}
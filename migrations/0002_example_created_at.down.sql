@@ -0,0 +1,3 @@
+DROP INDEX example_created_at_id_idx;
+
+ALTER TABLE example DROP COLUMN created_at;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE example ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- lint:ignore index-not-concurrent
+CREATE INDEX example_created_at_id_idx ON example (created_at, id);
\ No newline at end of file
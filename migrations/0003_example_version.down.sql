@@ -0,0 +1 @@
+ALTER TABLE example DROP COLUMN version;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE example DROP COLUMN owner;
\ No newline at end of file
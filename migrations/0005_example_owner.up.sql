@@ -0,0 +1 @@
+ALTER TABLE example ADD COLUMN owner TEXT NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1 @@
+REVOKE SELECT ON schema_migrations FROM example;
\ No newline at end of file
@@ -0,0 +1 @@
+GRANT SELECT ON schema_migrations TO example;
\ No newline at end of file
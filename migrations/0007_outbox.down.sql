@@ -0,0 +1,3 @@
+DROP TRIGGER outbox_notify ON outbox;
+DROP FUNCTION outbox_notify;
+DROP TABLE outbox;
\ No newline at end of file
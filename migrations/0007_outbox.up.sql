@@ -0,0 +1,24 @@
+CREATE TABLE outbox (
+    id UUID PRIMARY KEY,
+    type TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    published_at TIMESTAMPTZ,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX outbox_unpublished_idx ON outbox (occurred_at) WHERE published_at IS NULL;
+
+CREATE FUNCTION outbox_notify() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('outbox', NEW.id::TEXT);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER outbox_notify AFTER INSERT ON outbox
+    FOR EACH ROW EXECUTE FUNCTION outbox_notify();
+
+GRANT SELECT, INSERT, UPDATE ON outbox to example;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE webhook_attempt;
+DROP TABLE webhook_delivery;
+DROP TABLE webhook_subscription;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE rate_limit;
\ No newline at end of file
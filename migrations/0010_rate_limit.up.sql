@@ -0,0 +1,8 @@
+CREATE UNLOGGED TABLE rate_limit (
+    key TEXT PRIMARY KEY,
+    tat BIGINT NOT NULL
+);
+
+CREATE INDEX rate_limit_tat_idx ON rate_limit (tat);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON rate_limit to example;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE webhook_subscription DROP COLUMN all_owners;
+
+ALTER TABLE outbox DROP COLUMN owner;
\ No newline at end of file
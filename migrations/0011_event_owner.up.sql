@@ -0,0 +1,6 @@
+ALTER TABLE outbox ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+
+-- Events pending delivery are delivered to the webhooks of their owner.
+UPDATE outbox o SET owner = e.owner FROM example e WHERE o.aggregate_id = e.id AND o.published_at IS NULL;
+
+ALTER TABLE webhook_subscription ADD COLUMN all_owners BOOLEAN NOT NULL DEFAULT false;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE outbox DROP COLUMN dead_at;
+
+ALTER TABLE outbox DROP COLUMN next_attempt_at;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE outbox ADD COLUMN next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Set once the event failed to publish too many times, it is no longer
+-- published.
+ALTER TABLE outbox ADD COLUMN dead_at TIMESTAMPTZ;
\ No newline at end of file
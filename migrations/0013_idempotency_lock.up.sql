@@ -0,0 +1,3 @@
+-- In-progress keys may be taken over once their lock passed, existing ones
+-- right away.
+ALTER TABLE idempotency_key ADD COLUMN locked_until TIMESTAMPTZ NOT NULL DEFAULT now();
\ No newline at end of file
@@ -0,0 +1,11 @@
+// Package migrations embeds the database migrations, so the service binary
+// can check and apply them without the files.
+package migrations
+
+import "embed"
+
+// FS holds the migrations named <version>_<name>.(up|down).sql. init.sql
+// sets up the database of the local environment and is not embedded.
+//
+//go:embed *.up.sql *.down.sql
+var FS embed.FS